@@ -0,0 +1,389 @@
+package gora
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBulkIndexerClosed = errors.New("Bulk indexer is closed")
+)
+
+// BulkIndexerConfig configures a BulkIndexer. Zero values get defaults.
+type BulkIndexerConfig struct {
+	// BatchSize is the maximum number of adds and deletes in a batch,
+	// 100 by default
+	BatchSize int
+
+	// BatchBytes, if set, is the maximum size of a batch's request body.
+	// A single document larger than this is sent in a batch of its own.
+	BatchBytes int
+
+	// FlushInterval, if set, sends a batch that has been waiting this
+	// long, however small
+	FlushInterval time.Duration
+
+	// Concurrency is the number of batches in flight, 1 by default. Adds
+	// and deletes block once that many batches are waiting on the pool.
+	Concurrency int
+
+	// Commit is sent with every batch. Unlike with the update jobs, nil
+	// sends no commit, leaving it to Solr's autoCommit.
+	Commit *CommitOptions
+
+	// MaxErrors and UpdateChain make the batches tolerant updates, see
+	// SolrBatchUpdateQuery. The failed documents are reported in the
+	// UpdateErrors of the batch's Response.
+	MaxErrors   int
+	UpdateChain string
+
+	// OnBatch, if set, is called with the result of every batch, from
+	// the goroutine that waited on it
+	OnBatch func(*BulkBatchResult)
+}
+
+// BulkBatchResult is the outcome of a batch sent by a BulkIndexer.
+type BulkBatchResult struct {
+	Adds    int
+	Deletes int
+
+	// Response is the SolrResponse of the batch, or nil if it could not
+	// be submitted
+	Response *SolrResponse
+
+	// Err is the error of the batch, from the pool or from Solr
+	Err error
+}
+
+// BulkIndexer groups single adds and deletes into update batches, and
+// submits them through a Pool. Batches are sent when they are full, by
+// count or size, when FlushInterval has passed, and on Flush and Close.
+// A BulkIndexer is safe for concurrent use, but the order of updates
+// only holds within a batch when Concurrency is above 1.
+type BulkIndexer struct {
+	pool   *Pool
+	config BulkIndexerConfig
+
+	lock   sync.Mutex
+	batch  *bulkBatch
+	closed bool
+	err    error
+	sem    chan struct{}
+
+	// pending counts the batches sent but not yet reported, drained is
+	// closed when it drops to zero, and room whenever it drops
+	pending int
+	drained chan struct{}
+	room    chan struct{}
+
+	// sent is closed once the last batch sent is in the pool
+	sent chan struct{}
+
+	stopCh  chan struct{}
+	stopped chan struct{}
+}
+
+// NewBulkIndexer creates a BulkIndexer submitting its batches to a
+// running Pool.
+func NewBulkIndexer(p *Pool, config BulkIndexerConfig) *BulkIndexer {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	if config.Commit == nil {
+		config.Commit = NoCommit()
+	}
+
+	b := &BulkIndexer{
+		pool:    p,
+		config:  config,
+		sem:     make(chan struct{}, config.Concurrency),
+		drained: make(chan struct{}),
+		room:    make(chan struct{}),
+		sent:    make(chan struct{}),
+		stopCh:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	close(b.drained)
+	close(b.sent)
+
+	go b.flushPeriodically()
+
+	return b
+}
+
+// Add queues a document. It blocks while Concurrency batches are in
+// flight, or until the context is done, in which case the document is
+// not queued.
+func (b *BulkIndexer) Add(ctx context.Context, doc map[string]interface{}) error {
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return b.queue(ctx, addCommand(encoded, b.config.Commit.CommitWithin), true)
+}
+
+// Delete queues the deletion of a document by id. It blocks like Add.
+func (b *BulkIndexer) Delete(ctx context.Context, id string) error {
+	return b.queue(ctx, deleteCommand(DeleteByID{ID: id}, b.config.Commit.CommitWithin), false)
+}
+
+func (b *BulkIndexer) queue(ctx context.Context, command string, add bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// Wait for room before the update joins a batch: once in, it is sent
+	// whatever happens to this caller's context.
+	for !b.closed && b.pending >= b.config.Concurrency {
+		room := b.room
+		b.lock.Unlock()
+
+		select {
+		case <-room:
+			b.lock.Lock()
+		case <-ctx.Done():
+			b.lock.Lock()
+			return ctx.Err()
+		}
+	}
+
+	if b.closed {
+		return ErrBulkIndexerClosed
+	}
+
+	if b.batch != nil && b.config.BatchBytes > 0 && b.batch.size+len(command)+1 > b.config.BatchBytes {
+		b.sendBatch()
+	}
+
+	if b.batch == nil {
+		b.batch = newBulkBatch("update", tolerantParams(b.config.MaxErrors, b.config.UpdateChain), b.config.Commit)
+	}
+	b.batch.append(command, add)
+
+	if len(b.batch.commands) >= b.config.BatchSize {
+		b.sendBatch()
+	}
+
+	return nil
+}
+
+// sendBatch removes the current batch, if any, and sends it in the
+// background. The batch is pending until its result is reported. The
+// lock must be held.
+func (b *BulkIndexer) sendBatch() {
+	batch := b.batch
+	if batch == nil {
+		return
+	}
+	b.batch = nil
+
+	if b.pending == 0 {
+		b.drained = make(chan struct{})
+	}
+	b.pending++
+
+	// Batches are handed to the pool in the order they were sent
+	prev, next := b.sent, make(chan struct{})
+	b.sent = next
+
+	go b.submit(batch, prev, next)
+}
+
+// done marks a batch sent by sendBatch as reported.
+func (b *BulkIndexer) done() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.pending--
+	if b.pending == 0 {
+		close(b.drained)
+	}
+
+	close(b.room)
+	b.room = make(chan struct{})
+}
+
+// submit hands a batch to the pool once the batch before it is there,
+// and fewer than Concurrency batches are in flight, then waits for its
+// result. The batch holds the updates of other callers, so it does not
+// depend on any caller's context.
+func (b *BulkIndexer) submit(batch *bulkBatch, prev <-chan struct{}, next chan<- struct{}) {
+	defer b.done()
+
+	<-prev
+	b.sem <- struct{}{}
+
+	err := b.pool.Submit(batch)
+	close(next)
+
+	if err != nil {
+		<-b.sem
+		b.report(batch, nil, err)
+		return
+	}
+
+	resp := batch.Wait()
+	<-b.sem
+	b.report(batch, resp, resp.Error)
+}
+
+func (b *BulkIndexer) report(batch *bulkBatch, resp *SolrResponse, err error) {
+	if err != nil {
+		b.lock.Lock()
+		if b.err == nil {
+			b.err = err
+		}
+		b.lock.Unlock()
+	}
+
+	if b.config.OnBatch != nil {
+		b.config.OnBatch(&BulkBatchResult{
+			Adds:     batch.adds,
+			Deletes:  len(batch.commands) - batch.adds,
+			Response: resp,
+			Err:      err,
+		})
+	}
+}
+
+// Flush sends the current batch and waits for every batch in flight.
+// It returns the first batch error since the last Flush, if any.
+func (b *BulkIndexer) Flush(ctx context.Context) error {
+	b.lock.Lock()
+	b.sendBatch()
+	drained := b.drained
+	b.lock.Unlock()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	b.lock.Lock()
+	err := b.err
+	b.err = nil
+	b.lock.Unlock()
+
+	return err
+}
+
+// Close flushes the indexer, which then refuses further updates.
+func (b *BulkIndexer) Close(ctx context.Context) error {
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return ErrBulkIndexerClosed
+	}
+	b.closed = true
+	b.lock.Unlock()
+
+	close(b.stopCh)
+	<-b.stopped
+
+	return b.Flush(ctx)
+}
+
+// flushPeriodically sends the batches that have waited for FlushInterval.
+func (b *BulkIndexer) flushPeriodically() {
+	defer close(b.stopped)
+
+	if b.config.FlushInterval <= 0 {
+		<-b.stopCh
+		return
+	}
+
+	ticker := time.NewTicker(b.config.FlushInterval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+
+		case <-ticker.C:
+			b.lock.Lock()
+			if b.batch != nil && time.Since(b.batch.created) >= b.config.FlushInterval {
+				b.sendBatch()
+			}
+			b.lock.Unlock()
+		}
+	}
+}
+
+// bulkBatch is the update job of a BulkIndexer, a list of add and
+// delete commands.
+type bulkBatch struct {
+	commands []string
+	adds     int
+	size     int
+	created  time.Time
+	commit   *CommitOptions
+	handler  string
+	params   url.Values
+	resultCh chan *SolrResponse
+}
+
+func newBulkBatch(handler string, params url.Values, commit *CommitOptions) *bulkBatch {
+	batch := &bulkBatch{
+		handler:  handler,
+		params:   params,
+		created:  time.Now(),
+		commit:   commit,
+		size:     len("{}"),
+		resultCh: make(chan *SolrResponse, 1),
+	}
+
+	if cmd := commit.command(); len(cmd) > 0 {
+		batch.size += len(", ") + len(cmd)
+	}
+
+	return batch
+}
+
+func (q *bulkBatch) append(command string, add bool) {
+	q.commands = append(q.commands, command)
+	q.size += len(command) + 1
+
+	if add {
+		q.adds++
+	}
+}
+
+func (q *bulkBatch) Handler() string {
+	return q.handler
+}
+
+func (q *bulkBatch) URLParams() url.Values {
+	return q.params
+}
+
+func (q *bulkBatch) ResultCh() chan *SolrResponse {
+	return q.resultCh
+}
+
+func (q *bulkBatch) Wait() *SolrResponse {
+	return <-q.ResultCh()
+}
+
+func (q *bulkBatch) GetRows() int {
+	return 0
+}
+
+func (q *bulkBatch) GetStart() int {
+	return 0
+}
+
+func (q *bulkBatch) Bytes() []byte {
+	return updateBody(strings.Join(q.commands, ","), q.commit)
+}
@@ -0,0 +1,245 @@
+package gora
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingClient keeps the body of every job, and fails the jobs
+// whose body contains failOn.
+type recordingClient struct {
+	MockSolrClient
+	lock   sync.Mutex
+	bodies []string
+	failOn string
+	delay  time.Duration
+}
+
+func (c *recordingClient) ExecuteContext(ctx context.Context, s SolrJob) (*SolrResponse, bool) {
+	time.Sleep(c.delay)
+
+	body := string(s.Bytes())
+	c.lock.Lock()
+	c.bodies = append(c.bodies, body)
+	c.lock.Unlock()
+
+	if len(c.failOn) > 0 && strings.Contains(body, c.failOn) {
+		return &SolrResponse{Status: 400, Error: &SolrError{Code: 400, Msg: "bad document"}}, false
+	}
+
+	return &SolrResponse{}, false
+}
+
+func (c *recordingClient) requests() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string(nil), c.bodies...)
+}
+
+func TestBulkIndexerBatchSize(t *testing.T) {
+	client := &recordingClient{}
+	p := NewPool([]SolrClient{client}, 2, 0, 1)
+	sig, _ := p.Run()
+
+	var lock sync.Mutex
+	var results []*BulkBatchResult
+	b := NewBulkIndexer(p, BulkIndexerConfig{
+		BatchSize: 2,
+		OnBatch: func(r *BulkBatchResult) {
+			lock.Lock()
+			results = append(results, r)
+			lock.Unlock()
+		},
+	})
+
+	ctx := context.Background()
+	b.Add(ctx, map[string]interface{}{"id": "1"})
+	b.Delete(ctx, "2")
+	b.Add(ctx, map[string]interface{}{"id": "3"})
+
+	if err := b.Close(ctx); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	requests := client.requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 batches, got %v", requests)
+	}
+
+	if requests[0] != `{"add":{"doc":{"id":"1"}},"delete":{"id":"2"}}` {
+		t.Errorf("Unexpected batch %s", requests[0])
+	}
+
+	if requests[1] != `{"add":{"doc":{"id":"3"}}}` {
+		t.Errorf("Unexpected batch %s", requests[1])
+	}
+
+	if len(results) != 2 || results[0].Adds != 1 || results[0].Deletes != 1 || results[0].Err != nil {
+		t.Errorf("Unexpected results %+v", results)
+	}
+
+	if err := b.Add(ctx, map[string]interface{}{"id": "4"}); err != ErrBulkIndexerClosed {
+		t.Errorf("Expected %v. Got %v.", ErrBulkIndexerClosed, err)
+	}
+
+	p.Stop()
+	<-sig
+}
+
+func TestBulkIndexerBatchBytes(t *testing.T) {
+	client := &recordingClient{}
+	p := NewPool([]SolrClient{client}, 1, 0, 1)
+	sig, _ := p.Run()
+
+	b := NewBulkIndexer(p, BulkIndexerConfig{BatchBytes: 90, Commit: SoftCommit()})
+
+	ctx := context.Background()
+	for _, id := range []string{"1", "2", "3"} {
+		b.Add(ctx, map[string]interface{}{"id": id})
+	}
+	b.Close(ctx)
+
+	requests := client.requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 batches, got %v", requests)
+	}
+
+	for _, r := range requests {
+		if len(r) > 90 || !strings.HasSuffix(r, `, "commit": {"softCommit":true}}`) {
+			t.Errorf("Unexpected batch %s", r)
+		}
+	}
+
+	p.Stop()
+	<-sig
+}
+
+func TestBulkIndexerFlushInterval(t *testing.T) {
+	client := &recordingClient{}
+	p := NewPool([]SolrClient{client}, 1, 0, 1)
+	sig, _ := p.Run()
+
+	b := NewBulkIndexer(p, BulkIndexerConfig{FlushInterval: 20 * time.Millisecond})
+	b.Add(context.Background(), map[string]interface{}{"id": "1"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.requests()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if len(client.requests()) != 1 {
+		t.Errorf("Expected the batch to be flushed")
+	}
+
+	b.Close(context.Background())
+	p.Stop()
+	<-sig
+}
+
+func TestBulkIndexerErrors(t *testing.T) {
+	client := &recordingClient{failOn: `"bad"`}
+	p := NewPool([]SolrClient{client}, 1, 0, 1)
+	sig, _ := p.Run()
+
+	b := NewBulkIndexer(p, BulkIndexerConfig{BatchSize: 1, Concurrency: 2})
+
+	ctx := context.Background()
+	b.Add(ctx, map[string]interface{}{"id": "ok"})
+	b.Add(ctx, map[string]interface{}{"id": "bad"})
+
+	var solrErr *SolrError
+	if err := b.Flush(ctx); !errors.As(err, &solrErr) {
+		t.Errorf("Expected a SolrError, got %v", err)
+	}
+
+	if err := b.Flush(ctx); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	b.Close(ctx)
+	p.Stop()
+	<-sig
+}
+
+func TestBulkIndexerBackpressure(t *testing.T) {
+	client := &recordingClient{delay: 200 * time.Millisecond}
+	p := NewPool([]SolrClient{client}, 1, 0, 1)
+	sig, _ := p.Run()
+
+	b := NewBulkIndexer(p, BulkIndexerConfig{BatchSize: 1})
+
+	ctx := context.Background()
+	b.Add(ctx, map[string]interface{}{"id": "1"})
+
+	// The first batch is in flight, the second has to wait for it
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	if err := b.Add(timeout, map[string]interface{}{"id": "2"}); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v. Got %v.", context.DeadlineExceeded, err)
+	}
+
+	// The refused document was left out, the accepted one was sent
+	if err := b.Close(ctx); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if requests := client.requests(); len(requests) != 1 || strings.Contains(requests[0], `"2"`) {
+		t.Errorf("Unexpected requests %v", requests)
+	}
+
+	p.Stop()
+	<-sig
+}
+
+func TestBulkIndexerCancelledCaller(t *testing.T) {
+	client := &recordingClient{delay: 100 * time.Millisecond}
+	p := NewPool([]SolrClient{client}, 1, 0, 1)
+	sig, _ := p.Run()
+
+	var lock sync.Mutex
+	var results []*BulkBatchResult
+	b := NewBulkIndexer(p, BulkIndexerConfig{BatchSize: 2, OnBatch: func(r *BulkBatchResult) {
+		lock.Lock()
+		results = append(results, r)
+		lock.Unlock()
+	}})
+
+	ctx := context.Background()
+	b.Add(ctx, map[string]interface{}{"id": "1"})
+	b.Add(ctx, map[string]interface{}{"id": "2"})
+	b.Add(ctx, map[string]interface{}{"id": "3"})
+
+	// The batch completed by a caller that has given up holds another
+	// caller's document, and is sent all the same
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	b.Add(cancelled, map[string]interface{}{"id": "4"})
+
+	if err := b.Close(ctx); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	adds := 0
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("Unexpected batch error %v", r.Err)
+		}
+		adds += r.Adds
+	}
+
+	if adds != 4 {
+		t.Errorf("Expected 4 documents to be sent, got %d", adds)
+	}
+
+	p.Stop()
+	<-sig
+}
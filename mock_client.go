@@ -1,6 +1,9 @@
 package gora
 
-import "strconv"
+import (
+	"context"
+	"strconv"
+)
 
 type MockSolrClient struct {
 	Timeout      bool
@@ -28,6 +31,14 @@ func (c *MockSolrClient) Execute(s SolrJob) (*SolrResponse, bool) {
 	return c.Response, c.Timeout
 }
 
+func (c *MockSolrClient) ExecuteContext(ctx context.Context, s SolrJob) (*SolrResponse, bool) {
+	if ctx.Err() != nil {
+		return &SolrResponse{Error: ctx.Err()}, false
+	}
+
+	return c.Execute(s)
+}
+
 func (c *MockSolrClient) TestConnection() bool {
 	c.ConnectCount += 1
 
@@ -35,7 +46,7 @@ func (c *MockSolrClient) TestConnection() bool {
 		c.CloseCh <- struct{}{}
 	}
 
-	return c.Timeout
+	return !c.Timeout
 }
 
 func MockClientConstructor(hostUrl string, core string, ch chan struct{}) SolrClient {
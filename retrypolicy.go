@@ -0,0 +1,185 @@
+package gora
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether a failed job should be tried again, and
+// how long to wait before doing so. It is consulted by HttpSolrClient
+// before reporting a failure, and by the Pool workers for the clients
+// without a policy of their own, so that a job is only ever retried by
+// one of them. The workers also use Backoff to space out their
+// reconnection attempts.
+type RetryPolicy interface {
+	// ShouldRetry reports whether a job that failed with err on
+	// its attempt-th try (starting at 1) should be tried again.
+	ShouldRetry(attempt int, err error) bool
+
+	// Backoff returns how long to wait after the attempt-th failure.
+	Backoff(attempt int) time.Duration
+}
+
+// defaultMaxDelay caps the backoff of a BackoffRetryPolicy without a
+// MaxDelay.
+const defaultMaxDelay = time.Minute
+
+// BackoffRetryPolicy is a RetryPolicy with exponential backoff and jitter.
+// The delay after the n-th failure is InitialDelay * Multiplier^(n-1),
+// capped at MaxDelay, of which up to a Jitter fraction is randomly removed.
+type BackoffRetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one
+	MaxAttempts int
+
+	InitialDelay time.Duration
+
+	// MaxDelay caps the delay, at a minute if zero
+	MaxDelay   time.Duration
+	Multiplier float64
+
+	// Jitter is the fraction of the delay that is randomised, in [0, 1]
+	Jitter float64
+
+	// RetryOn decides which errors are worth retrying. IsRetryableError is
+	// used if nil.
+	RetryOn func(error) bool
+}
+
+// NewBackoffRetryPolicy creates a BackoffRetryPolicy that doubles its delay
+// after every failure, randomising half of it.
+func NewBackoffRetryPolicy(maxAttempts int, initialDelay, maxDelay time.Duration) *BackoffRetryPolicy {
+	return &BackoffRetryPolicy{
+		MaxAttempts:  maxAttempts,
+		InitialDelay: initialDelay,
+		MaxDelay:     maxDelay,
+		Multiplier:   2,
+		Jitter:       0.5,
+	}
+}
+
+func (p *BackoffRetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if p.RetryOn != nil {
+		return p.RetryOn(err)
+	}
+
+	return IsRetryableError(err)
+}
+
+func (p *BackoffRetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxDelay
+	}
+
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay -= delay * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// IsRetryableError reports whether err is likely to go away on its own:
+// network timeouts, refused or reset connections, and Solr answering
+// with 429 (too many requests) or 502, 503 and 504 (unavailable).
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if err == ErrTimeout {
+		return true
+	}
+
+	var streamErr *StreamInterruptedError
+	if errors.As(err, &streamErr) {
+		return false
+	}
+
+	// Could have an internal buffer problem, we should try again
+	if err == bytes.ErrTooLarge {
+		return true
+	}
+
+	var solrErr *SolrError
+	if errors.As(err, &solrErr) {
+		status := solrErr.HTTPStatus
+		if status == 0 {
+			status = solrErr.Code
+		}
+
+		switch status {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	// If we get a totally unknown error, send it back to the caller
+	urlError, ok := err.(*url.Error)
+	if !ok {
+		return false
+	}
+
+	return urlError.Temporary() || urlError.Timeout()
+}
+
+// shouldRetry consults a RetryPolicy, if any. A streaming job that has
+// handed documents to its callback is never retried, whatever the policy.
+func shouldRetry(p RetryPolicy, attempt int, err error) bool {
+	var streamErr *StreamInterruptedError
+	if p == nil || errors.As(err, &streamErr) {
+		return false
+	}
+
+	return p.ShouldRetry(attempt, err)
+}
+
+// retryingClient is a SolrClient retrying failed jobs on its own, see
+// HttpSolrClient.RetryPolicy.
+type retryingClient interface {
+	retryPolicy() RetryPolicy
+}
+
+// sleepContext waits for d to elapse, or for the context to be done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
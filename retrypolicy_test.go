@@ -0,0 +1,90 @@
+package gora
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBackoffRetryPolicy(t *testing.T) {
+	p := NewBackoffRetryPolicy(3, 100*time.Millisecond, time.Second)
+	p.Jitter = 0
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+
+	for i, d := range expected {
+		if p.Backoff(i+1) != d {
+			t.Errorf("Expected backoff %v for attempt %d, got %v", d, i+1, p.Backoff(i+1))
+		}
+	}
+
+	// Without a MaxDelay the delay stays finite
+	p.MaxDelay = 0
+	if d := p.Backoff(5000); d != defaultMaxDelay {
+		t.Errorf("Expected backoff %v without MaxDelay, got %v", defaultMaxDelay, d)
+	}
+	p.MaxDelay = time.Second
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(2)
+		if d < 100*time.Millisecond || d > 200*time.Millisecond {
+			t.Fatalf("Jittered backoff %v out of range", d)
+		}
+	}
+
+	if !p.ShouldRetry(1, ErrTimeout) || !p.ShouldRetry(2, ErrTimeout) {
+		t.Error("Expected a retry before MaxAttempts")
+	}
+
+	if p.ShouldRetry(3, ErrTimeout) {
+		t.Error("Expected no retry after MaxAttempts")
+	}
+
+	p.RetryOn = func(err error) bool { return false }
+	if p.ShouldRetry(1, ErrTimeout) {
+		t.Error("Expected RetryOn to be honoured")
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	connRefused := &url.Error{Op: "Post", URL: "http://localhost", Err: syscall.ECONNREFUSED}
+
+	retryable := []error{
+		ErrTimeout,
+		bytes.ErrTooLarge,
+		connRefused,
+		&SolrError{HTTPStatus: 503},
+		&SolrError{Code: 429},
+	}
+
+	for _, err := range retryable {
+		if !IsRetryableError(err) {
+			t.Errorf("Expected %#v to be retryable", err)
+		}
+	}
+
+	permanent := []error{
+		nil,
+		errors.New("unknown"),
+		&SolrError{HTTPStatus: 400},
+		&SolrError{HTTPStatus: 404},
+		&SolrError{HTTPStatus: 500},
+	}
+
+	for _, err := range permanent {
+		if IsRetryableError(err) {
+			t.Errorf("Expected %#v not to be retryable", err)
+		}
+	}
+}
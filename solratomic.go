@@ -0,0 +1,124 @@
+package gora
+
+import (
+	"reflect"
+)
+
+// Atomic update modifiers, see AtomicUpdate.
+const (
+	ModifierSet         = "set"
+	ModifierAdd         = "add"
+	ModifierAddDistinct = "add-distinct"
+	ModifierRemove      = "remove"
+	ModifierRemoveRegex = "removeregex"
+	ModifierInc         = "inc"
+)
+
+// AtomicUpdate is a partial document, updating some fields of an indexed
+// document in place instead of replacing it. Solr rebuilds the rest of
+// the document from its stored fields, so every field of the schema must
+// be stored or have docValues.
+//
+// An AtomicUpdate is sent like any document, with NewSolrUpdateQuery or
+// NewSolrAtomicUpdateQuery.
+type AtomicUpdate map[string]interface{}
+
+// NewAtomicUpdate creates an AtomicUpdate for the document with the
+// given unique key value.
+func NewAtomicUpdate(uniqueKey string, id interface{}) AtomicUpdate {
+	return AtomicUpdate{uniqueKey: id}
+}
+
+// Modify applies a modifier to a field. A field may have several
+// modifiers, e.g. both "add" and "remove". The values of a list modifier
+// ("add", "add-distinct", "remove" and "removeregex") applied twice are
+// appended to each other; other modifiers replace their previous value.
+func (u AtomicUpdate) Modify(modifier, field string, value interface{}) AtomicUpdate {
+	modifiers, ok := u[field].(map[string]interface{})
+	if !ok {
+		modifiers = make(map[string]interface{}, 1)
+		u[field] = modifiers
+	}
+
+	switch existing, ok := modifiers[modifier]; {
+	case ok && listModifier(modifier):
+		modifiers[modifier] = append(valueList(existing), valueList(value)...)
+	default:
+		modifiers[modifier] = value
+	}
+
+	return u
+}
+
+func listModifier(modifier string) bool {
+	switch modifier {
+	case ModifierAdd, ModifierAddDistinct, ModifierRemove, ModifierRemoveRegex:
+		return true
+	}
+
+	return false
+}
+
+// valueList returns the values of a list modifier, a single value being
+// a list of one.
+func valueList(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []interface{}{v}
+	}
+
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+
+	return list
+}
+
+// Set replaces the value of a field. A nil value removes the field.
+func (u AtomicUpdate) Set(field string, value interface{}) AtomicUpdate {
+	return u.Modify(ModifierSet, field, value)
+}
+
+// Add appends values to a multi-valued field.
+func (u AtomicUpdate) Add(field string, values ...interface{}) AtomicUpdate {
+	return u.Modify(ModifierAdd, field, values)
+}
+
+// AddDistinct appends the values not yet in a multi-valued field.
+func (u AtomicUpdate) AddDistinct(field string, values ...interface{}) AtomicUpdate {
+	return u.Modify(ModifierAddDistinct, field, values)
+}
+
+// Remove removes all occurrences of values from a multi-valued field.
+func (u AtomicUpdate) Remove(field string, values ...interface{}) AtomicUpdate {
+	return u.Modify(ModifierRemove, field, values)
+}
+
+// RemoveRegex removes the values matching any of the patterns from a
+// multi-valued field.
+func (u AtomicUpdate) RemoveRegex(field string, patterns ...string) AtomicUpdate {
+	return u.Modify(ModifierRemoveRegex, field, patterns)
+}
+
+// Inc increments a numeric field by delta, which may be negative.
+func (u AtomicUpdate) Inc(field string, delta interface{}) AtomicUpdate {
+	return u.Modify(ModifierInc, field, delta)
+}
+
+// Version sets the expected _version_ of the document, see SetVersion.
+func (u AtomicUpdate) Version(version int64) AtomicUpdate {
+	SetVersion(u, version)
+	return u
+}
+
+// NewSolrAtomicUpdateQuery creates a SolrBatchUpdateQuery sending
+// atomic updates.
+func NewSolrAtomicUpdateQuery(updates ...AtomicUpdate) *SolrBatchUpdateQuery {
+	docs := make([]map[string]interface{}, len(updates))
+	for i, u := range updates {
+		docs[i] = u
+	}
+
+	return NewSolrBatchUpdateQuery(docs)
+}
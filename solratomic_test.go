@@ -0,0 +1,68 @@
+package gora
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAtomicUpdate(t *testing.T) {
+	update := NewAtomicUpdate("id", "doc:1").
+		Set("title", "Red shoes").
+		Set("discount_f", nil).
+		Inc("views_i", 1).
+		Add("tags_ss", "sale", "summer").
+		Remove("tags_ss", "new").
+		AddDistinct("colors_ss", "red").
+		RemoveRegex("labels_ss", "^tmp_.*")
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(NewSolrUpdateQuery(update).Bytes(), &doc); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	expected := map[string]interface{}{
+		"id":         "doc:1",
+		"title":      map[string]interface{}{"set": "Red shoes"},
+		"discount_f": map[string]interface{}{"set": nil},
+		"views_i":    map[string]interface{}{"inc": float64(1)},
+		"tags_ss": map[string]interface{}{
+			"add":    []interface{}{"sale", "summer"},
+			"remove": []interface{}{"new"},
+		},
+		"colors_ss": map[string]interface{}{"add-distinct": []interface{}{"red"}},
+		"labels_ss": map[string]interface{}{"removeregex": []interface{}{"^tmp_.*"}},
+	}
+
+	added := doc["add"].(map[string]interface{})["doc"]
+	if !reflect.DeepEqual(added, expected) {
+		t.Errorf("Unexpected document %v", added)
+	}
+}
+
+func TestAtomicUpdateRepeated(t *testing.T) {
+	update := NewAtomicUpdate("id", "doc:1").
+		Add("tags_ss", "a").
+		Add("tags_ss", "b", "c").
+		RemoveRegex("labels_ss", "^tmp_").
+		RemoveRegex("labels_ss", "^old_").
+		Inc("views_i", 1).
+		Inc("views_i", 2)
+
+	expected := `{"add":{"doc":{"id":"doc:1","labels_ss":{"removeregex":["^tmp_","^old_"]},"tags_ss":{"add":["a","b","c"]},"views_i":{"inc":2}}}, "commit": {}}`
+	if b := string(NewSolrAtomicUpdateQuery(update).Bytes()); b != expected {
+		t.Errorf("Unexpected query %s", b)
+	}
+}
+
+func TestSolrAtomicUpdateQuery(t *testing.T) {
+	query := NewSolrAtomicUpdateQuery(
+		NewAtomicUpdate("id", "1").Inc("views_i", 1),
+		NewAtomicUpdate("id", "2").Inc("views_i", -1).Version(VersionMustExist),
+	)
+
+	expected := `{"add":{"doc":{"id":"1","views_i":{"inc":1}}},"add":{"doc":{"_version_":1,"id":"2","views_i":{"inc":-1}}}, "commit": {}}`
+	if string(query.Bytes()) != expected {
+		t.Errorf("Unexpected query %s", query.Bytes())
+	}
+}
@@ -2,10 +2,13 @@ package gora
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/wirelessregistry/glog"
 )
@@ -15,8 +18,9 @@ import (
 //
 // Execute(SolrJob) should always return a SolrRespose. If there was
 // an error executing the SolrJob, the error should be put in the
-// SolrResponse, and the retry flag should be set depending on whether
-// this error is recoverable or not.
+// SolrResponse, and the retry flag should be set if the host could not
+// be reached. An error reported by Solr itself, even a retryable one
+// such as a 503, leaves the flag unset: the host is up.
 //
 // TestConnection() will be called by the worker if an error has
 // previously occurred. The worker will not accept any new jobs
@@ -26,6 +30,29 @@ type SolrClient interface {
 	TestConnection() bool
 }
 
+// ContextSolrClient is a SolrClient that can abandon a request once
+// its context is done. A cancelled job is never flagged for retry.
+// Clients that don't implement it only have the context checked before
+// the job is executed.
+type ContextSolrClient interface {
+	SolrClient
+	ExecuteContext(context.Context, SolrJob) (*SolrResponse, bool)
+}
+
+// executeContext runs a job on a client, under the context if the
+// client supports it.
+func executeContext(ctx context.Context, client SolrClient, job SolrJob) (*SolrResponse, bool) {
+	if c, ok := client.(ContextSolrClient); ok {
+		return c.ExecuteContext(ctx, job)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &SolrResponse{Error: err}, false
+	}
+
+	return client.Execute(job)
+}
+
 type HttpSolrClient struct {
 	// Host specifies the URL of the Solr Server
 	Host string
@@ -33,6 +60,10 @@ type HttpSolrClient struct {
 	// Core specifies the Solr core to work with
 	Core string
 
+	// RetryPolicy, if set, is consulted before a failed job is reported
+	// back to the caller. Without one, every job is tried only once.
+	RetryPolicy RetryPolicy
+
 	username string
 	password string
 
@@ -87,7 +118,7 @@ func (c *HttpSolrClient) useAuth() bool {
 // As long as we don't get an error, we know that the Solr server
 // received the query, and that this connection is valid.
 func (c *HttpSolrClient) TestConnection() bool {
-	_, err := c.execQuery("", []byte(""))
+	_, _, err := c.execQuery(context.Background(), "", []byte(""))
 
 	if err != nil && glog.V(2) {
 		glog.Infof("HttpSolrClient.TestConnection() for %v failed. %v.", c.Host, err)
@@ -98,82 +129,216 @@ func (c *HttpSolrClient) TestConnection() bool {
 
 // Execute will send the given job to the Solr server and wait for
 // a response. If an error is received, the retry value will be determined
-// and the error will be placed in an empty SolrResponse.
+// and the error will be placed in an empty SolrResponse. If the client
+// has a RetryPolicy, the job is retried in place for as long as the
+// policy allows before the error is returned, and a Pool running the
+// job doesn't retry it again.
 func (c *HttpSolrClient) Execute(job SolrJob) (*SolrResponse, bool) {
+	return c.ExecuteContext(context.Background(), job)
+}
+
+// ExecuteContext is Execute with a context attached to the underlying
+// HTTP request. If the context is done before Solr answers, the context's
+// error is placed in the SolrResponse and the job is not retried.
+func (c *HttpSolrClient) ExecuteContext(ctx context.Context, job SolrJob) (*SolrResponse, bool) {
+	for attempt := 1; ; attempt++ {
+		resp, retry := c.execute(ctx, job)
+		if !shouldRetry(c.RetryPolicy, attempt, resp.Error) {
+			return resp, retry
+		}
+
+		glog.Warningf("HttpSolrClient.Execute() attempt %d failed, retrying. %v.", attempt, resp.Error)
+
+		if err := sleepContext(ctx, c.RetryPolicy.Backoff(attempt)); err != nil {
+			return &SolrResponse{Error: err}, false
+		}
+	}
+}
+
+func (c *HttpSolrClient) retryPolicy() RetryPolicy {
+	return c.RetryPolicy
+}
+
+// execute runs a single attempt of the job.
+func (c *HttpSolrClient) execute(ctx context.Context, job SolrJob) (*SolrResponse, bool) {
 	handler := job.Handler()
 	jobBytes := job.Bytes()
 
-	emptyResponse := &SolrResponse{}
-	byteResponse, err := c.execQuery(handler, jobBytes)
+	r, err := c.post(ctx, handler, urlParams(job), contentType(job), jobBytes)
 	if err != nil {
-		glog.Warningf("HttpSolrClient.execQuery() failed. %v.", err)
+		return c.requestFailed(ctx, err)
+	}
 
-		emptyResponse.Error = err
-		return emptyResponse, c.temporaryError(err)
+	defer r.Body.Close()
+
+	// delivered counts the documents handed to a streaming job's
+	// callback, and stopped is the callback's own error, if any
+	var delivered int
+	var stopped error
+
+	var solrResponse *SolrResponse
+	if onDoc := documentHandler(job); onDoc != nil {
+		solrResponse, err = SolrResponseFromReader(r.Body, func(doc map[string]interface{}) error {
+			delivered++
+			stopped = onDoc(doc)
+			return stopped
+		})
+	} else {
+		// read the response and check
+		byteResponse, readErr := ioutil.ReadAll(r.Body)
+		if readErr != nil {
+			return c.requestFailed(ctx, readErr)
+		}
+
+		solrResponse, err = SolrResponseFromHTTPResponse(byteResponse)
+		if err != nil && r.StatusCode < 400 {
+			glog.Errorf("Found %v", string(byteResponse))
+		}
 	}
 
-	solrResponse, err := SolrResponseFromHTTPResponse(byteResponse)
+	httpStatus := r.StatusCode
+	emptyResponse := &SolrResponse{}
 	if err != nil {
+		// Not a Solr response at all, e.g. a servlet container error page.
+		if httpStatus >= 400 {
+			emptyResponse.Status = httpStatus
+			emptyResponse.Error = statusError(c.solrError(&SolrError{Code: httpStatus}, handler, httpStatus))
+			return emptyResponse, false
+		}
+
+		if ctx.Err() != nil {
+			emptyResponse.Error = ctx.Err()
+			return emptyResponse, false
+		}
+
+		if delivered > 0 && err != stopped {
+			err = &StreamInterruptedError{Docs: delivered, Err: err}
+		}
+
+		// Failures reported within a streamed response
+		var solrErr *SolrError
+		if errors.As(err, &solrErr) {
+			c.solrError(solrErr, handler, httpStatus)
+			emptyResponse.Error = err
+			return emptyResponse, false
+		}
+
 		glog.Errorf("HttpSolrClient.SolrResponseFromHTTPResponse() failed. %v.", err)
-		glog.Errorf("Found %v", string(byteResponse))
 
 		emptyResponse.Error = err
 		return emptyResponse, false
 	}
 
+	var solrErr *SolrError
+	if errors.As(solrResponse.Error, &solrErr) {
+		c.solrError(solrErr, handler, httpStatus)
+	} else if solrResponse.Error == nil && httpStatus >= 400 {
+		solrResponse.Error = statusError(c.solrError(&SolrError{Code: httpStatus}, handler, httpStatus))
+	}
+
 	return solrResponse, false
 }
 
-// temporaryError tries to determine whether this error is recoverable.
-// Most errors will be type *url.Error, and we can ask that error
-// whether it is temporary, or timeout related.
-func (c *HttpSolrClient) temporaryError(err error) bool {
-	if err == nil {
-		return false
+// requestFailed creates the response for a request that could not be
+// sent, or whose response could not be read.
+func (c *HttpSolrClient) requestFailed(ctx context.Context, err error) (*SolrResponse, bool) {
+	emptyResponse := &SolrResponse{}
+
+	// The caller gave up on this job, the host is not to blame.
+	if ctx.Err() != nil {
+		emptyResponse.Error = ctx.Err()
+		return emptyResponse, false
+	}
+
+	glog.Warningf("HttpSolrClient.execQuery() failed. %v.", err)
+
+	emptyResponse.Error = err
+	return emptyResponse, IsRetryableError(err)
+}
+
+// documentHandler returns the callback of a streaming job, if any.
+func documentHandler(job SolrJob) func(map[string]interface{}) error {
+	if streaming, ok := job.(SolrStreamingJob); ok {
+		return streaming.DocumentHandler()
 	}
 
-	// Could have an internal buffer problem, we should try again
-	if err == bytes.ErrTooLarge {
-		return true
+	return nil
+}
+
+// urlParams returns the parameters a job sends in the URL, if any.
+func urlParams(job SolrJob) url.Values {
+	if j, ok := job.(SolrParamsJob); ok {
+		return j.URLParams()
 	}
 
-	// If we get a totally unknown error, send it back to the caller
-	urlError, ok := err.(*url.Error)
-	if !ok {
-		return false
+	return nil
+}
+
+// contentType returns the content type of a job's body, JSON unless
+// the job says otherwise.
+func contentType(job SolrJob) string {
+	if j, ok := job.(SolrContentTypeJob); ok && len(j.ContentType()) > 0 {
+		return j.ContentType()
 	}
 
-	return urlError.Temporary() || urlError.Timeout()
+	return "application/json"
 }
 
-// execQuery creates the full URL and posts an array of bytes to that url.
-func (c *HttpSolrClient) execQuery(handler string, json []byte) ([]byte, error) {
-	url := fmt.Sprintf("%s/solr/%s/%s", c.Host, c.Core, handler)
+// solrError fills in the request details of a SolrError.
+func (c *HttpSolrClient) solrError(e *SolrError, handler string, httpStatus int) *SolrError {
+	e.HTTPStatus = httpStatus
+	e.Handler = handler
+	e.URL = c.handlerURL(handler)
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(json))
+	return e
+}
+
+// handlerURL creates the full URL of a request handler.
+func (c *HttpSolrClient) handlerURL(handler string) string {
+	return fmt.Sprintf("%s/solr/%s/%s", c.Host, c.Core, handler)
+}
+
+// post creates the full URL, with params in its query string, and posts
+// an array of bytes to that url. The caller must close the body of the
+// returned response.
+func (c *HttpSolrClient) post(ctx context.Context, handler string, params url.Values, contentType string, body []byte) (*http.Response, error) {
+	reqURL := c.handlerURL(handler)
+	if len(params) > 0 {
+		sep := "?"
+		if strings.Contains(reqURL, "?") {
+			sep = "&"
+		}
+		reqURL += sep + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 
 	if c.useAuth() {
 		req.SetBasicAuth(c.username, c.password)
 	}
 
-	r, err := c.client.Do(req)
+	return c.client.Do(req)
+}
+
+// execQuery posts an array of bytes to a handler and reads the response.
+// The body is returned along with the HTTP status code of the response.
+func (c *HttpSolrClient) execQuery(ctx context.Context, handler string, json []byte) ([]byte, int, error) {
+	r, err := c.post(ctx, handler, nil, "application/json", json)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	defer r.Body.Close()
 
-	defer r.Body.Close()
-
 	// read the response and check
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		return nil, err
+		return nil, r.StatusCode, err
 	}
 
-	return body, nil
+	return body, r.StatusCode, nil
 }
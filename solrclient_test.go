@@ -2,13 +2,18 @@ package gora
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -110,6 +115,131 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+func TestStreamingQuery(t *testing.T) {
+	expected := bytes.NewBufferString(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {"numFound": 2, "start": 0, "docs": [{"id": "1"}, {"id": "2"}]}
+	}`)
+
+	server, client := createTestServer(expected, "/select")
+	defer server.Close()
+
+	ch := make(chan map[string]interface{}, 2)
+	solrQuery := NewSolrQuery("*:*", 0, 100, nil, nil, nil, "/select")
+	solrQuery.StreamDocuments(context.Background(), ch)
+
+	resp, _ := client.Execute(solrQuery)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error %s", resp.Error)
+	}
+	close(ch)
+
+	if resp.Response.NumFound != 2 || len(resp.Response.Docs) != 0 {
+		t.Errorf("Unexpected document collection %+v", resp.Response)
+	}
+
+	n := 0
+	for range ch {
+		n++
+	}
+
+	if n != 2 {
+		t.Errorf("Expected 2 streamed documents, found %d", n)
+	}
+}
+
+func TestStreamingQueryAbandoned(t *testing.T) {
+	expected := bytes.NewBufferString(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {"numFound": 2, "start": 0, "docs": [{"id": "1"}, {"id": "2"}]}
+	}`)
+
+	server, client := createTestServer(expected, "/select")
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Nobody reads the documents
+	ch := make(chan map[string]interface{})
+	solrQuery := NewSolrQuery("*:*", 0, 100, nil, nil, nil, "/select")
+	solrQuery.StreamDocuments(ctx, ch)
+
+	resp, _ := client.ExecuteContext(ctx, solrQuery)
+	if resp.Error != context.DeadlineExceeded {
+		t.Errorf("Expected %v. Got %v.", context.DeadlineExceeded, resp.Error)
+	}
+}
+
+// interruptedServer sends two documents, then drops the connection.
+func interruptedServer(requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		body := `{"responseHeader": {"status": 0, "QTime": 1},
+			"response": {"numFound": 5, "start": 0, "docs": [{"id": "1"}, {"id": "2"}, `
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(body)+100, body)
+		buf.Flush()
+	}))
+}
+
+func TestStreamingQueryInterrupted(t *testing.T) {
+	var requests int32
+	server := interruptedServer(&requests)
+	defer server.Close()
+
+	// Even a policy retrying everything leaves a started stream alone
+	retryAll := NewBackoffRetryPolicy(3, time.Millisecond, time.Millisecond)
+	retryAll.RetryOn = func(error) bool { return true }
+
+	client := NewHttpSolrClient(server.URL, "core").(*HttpSolrClient)
+	client.RetryPolicy = retryAll
+
+	docs := 0
+	solrQuery := NewSolrQuery("*:*", 0, 100, nil, nil, nil, "select")
+	solrQuery.OnDocument = func(doc map[string]interface{}) error {
+		docs++
+		return nil
+	}
+
+	resp, _ := client.Execute(solrQuery)
+	var streamErr *StreamInterruptedError
+	if !errors.As(resp.Error, &streamErr) || streamErr.Docs != 2 {
+		t.Errorf("Expected an interrupted stream, got %v", resp.Error)
+	}
+
+	if IsRetryableError(resp.Error) {
+		t.Error("An interrupted stream should not be retryable")
+	}
+
+	if docs != 2 || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected 2 documents from 1 request, got %d from %d", docs, requests)
+	}
+
+	// Nor is it retried by the pool
+	client.RetryPolicy = nil
+	p := NewPoolWithRetryPolicy([]SolrClient{client}, 1, 0, 1, retryAll)
+	sig, _ := p.Run()
+
+	docs = 0
+	atomic.StoreInt32(&requests, 0)
+	p.Submit(solrQuery)
+	solrQuery.Wait()
+
+	if docs != 2 || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected 2 documents from 1 request, got %d from %d", docs, requests)
+	}
+
+	p.Stop()
+	<-sig
+}
+
 func TestErrorQuery(t *testing.T) {
 	expected := bytes.NewBufferString(`{
 		    "responseHeader": {
@@ -138,6 +268,130 @@ func TestErrorQuery(t *testing.T) {
 	if resp != nil && resp.Error.Error() != "ERROR: [doc=change.me] unknown field 'title'" {
 		t.Errorf("Expected error message status, found %s", resp.Error)
 	}
+
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) {
+		t.Fatalf("Expected a SolrError, found %v", resp.Error)
+	}
+
+	if solrErr.Handler != "/select" || solrErr.URL != client.Host+"/solr/core//select" {
+		t.Errorf("Unexpected origin %s %s", solrErr.Handler, solrErr.URL)
+	}
+}
+
+func TestNotFoundQuery(t *testing.T) {
+	expected := bytes.NewBufferString(`{}`)
+	server, client := createTestServer(expected, "/select")
+	defer server.Close()
+
+	solrQuery := NewSolrQuery("*:*", 0, 100, nil, nil, nil, "missing")
+	resp, retry := client.Execute(solrQuery)
+	if retry {
+		t.Error("We should not retry this job")
+	}
+
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) {
+		t.Fatalf("Expected a SolrError, found %v", resp.Error)
+	}
+
+	if solrErr.HTTPStatus != 404 {
+		t.Errorf("Expected 404 status, found %d", solrErr.HTTPStatus)
+	}
+}
+
+func TestVersionConflict(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, `{
+			"responseHeader": {"status": 409, "QTime": 1},
+			"error": {"msg": "version conflict for 1 expected=1 actual=1634567890123456789", "code": 409}
+		}`)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewHttpSolrClient(server.URL, "core")
+
+	solrQuery := NewSolrUpdateQuery(map[string]interface{}{"id": "1"})
+	solrQuery.Version = 1
+
+	resp, retry := client.Execute(solrQuery)
+	if retry {
+		t.Error("We should not retry this job")
+	}
+
+	var conflict *VersionConflictError
+	if !errors.As(resp.Error, &conflict) {
+		t.Fatalf("Expected a VersionConflictError, found %v", resp.Error)
+	}
+
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) || solrErr.HTTPStatus != 409 || solrErr.Handler != "update" {
+		t.Errorf("Unexpected SolrError %+v", solrErr)
+	}
+}
+
+func TestVersionRoundTrip(t *testing.T) {
+	const version = "1634567890123456789"
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/select") {
+			io.WriteString(w, `{
+				"responseHeader": {"status": 0, "QTime": 1},
+				"response": {"numFound": 1, "start": 0, "docs": [{"id": "1", "_version_": `+version+`}]}
+			}`)
+			return
+		}
+
+		// Solr only takes the update with the exact version
+		body, _ := ioutil.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"_version_":`+version) {
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `{
+				"responseHeader": {"status": 409, "QTime": 1},
+				"error": {"msg": "version conflict for 1", "code": 409}
+			}`)
+			return
+		}
+
+		io.WriteString(w, `{"responseHeader": {"status": 0, "QTime": 1}}`)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewHttpSolrClient(server.URL, "core")
+
+	resp, _ := client.Execute(NewSolrQuery("id:1", 0, 1, nil, nil, nil, "select"))
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error %v", resp.Error)
+	}
+
+	if v, ok := resp.Response.Docs[0]["_version_"].(int64); !ok || strconv.FormatInt(v, 10) != version {
+		t.Errorf("Expected version %s, got %v", version, resp.Response.Docs[0]["_version_"])
+	}
+
+	var doc struct {
+		ID      string `solr:"id"`
+		Version int64  `solr:"_version_"`
+	}
+	if err := DecodeDocument(resp.Response.Docs[0], &doc); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if strconv.FormatInt(doc.Version, 10) != version {
+		t.Errorf("Expected version %s, got %d", version, doc.Version)
+	}
+
+	update := map[string]interface{}{"id": "1", "name": "updated"}
+	SetVersion(update, doc.Version)
+
+	resp, _ = client.Execute(NewSolrUpdateQuery(update))
+	if resp.Error != nil {
+		t.Errorf("Unexpected error %v", resp.Error)
+	}
 }
 
 func TestTestConnection(t *testing.T) {
@@ -185,3 +439,107 @@ func TestBadDecode(t *testing.T) {
 		t.Error("We should not retry this job")
 	}
 }
+
+func TestExecuteContext(t *testing.T) {
+	block := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	defer close(block)
+
+	client := NewHttpSolrClient(server.URL, "core")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	solrQuery := NewSolrQuery("*:*", 0, 100, nil, nil, nil, "select")
+	resp, retry := client.(ContextSolrClient).ExecuteContext(ctx, solrQuery)
+	if retry {
+		t.Error("We should not retry a cancelled job")
+	}
+
+	if resp.Error != context.DeadlineExceeded {
+		t.Errorf("Expected %v. Got %v.", context.DeadlineExceeded, resp.Error)
+	}
+}
+
+// executeOnlyClient is a SolrClient without ExecuteContext.
+type executeOnlyClient struct {
+	executed int
+}
+
+func (c *executeOnlyClient) Execute(s SolrJob) (*SolrResponse, bool) {
+	c.executed++
+	return &SolrResponse{}, false
+}
+
+func (c *executeOnlyClient) TestConnection() bool {
+	return true
+}
+
+func TestExecuteContextFallback(t *testing.T) {
+	client := &executeOnlyClient{}
+	solrQuery := NewSolrQuery("*:*", 0, 100, nil, nil, nil, "select")
+
+	resp, _ := executeContext(context.Background(), client, solrQuery)
+	if resp.Error != nil || client.executed != 1 {
+		t.Errorf("Expected the job to be executed. Got %v.", resp.Error)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp, retry := executeContext(ctx, client, solrQuery)
+	if retry || resp.Error != context.Canceled {
+		t.Errorf("Expected %v. Got %v.", context.Canceled, resp.Error)
+	}
+
+	if client.executed != 1 {
+		t.Error("A cancelled job should not be executed")
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	failures := 2
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"responseHeader": {"status": 0, "QTime": 1}}`)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewHttpSolrClient(server.URL, "core").(*HttpSolrClient)
+	solrQuery := NewSolrQuery("*:*", 0, 100, nil, nil, nil, "select")
+
+	resp, timeout := client.Execute(solrQuery)
+	if timeout {
+		t.Error("A 503 should not take the host offline")
+	}
+
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) || solrErr.HTTPStatus != 503 {
+		t.Errorf("Expected a 503 SolrError, found %v", resp.Error)
+	}
+
+	if !IsRetryableError(resp.Error) {
+		t.Error("A 503 should be retried")
+	}
+
+	client.RetryPolicy = NewBackoffRetryPolicy(3, time.Millisecond, 10*time.Millisecond)
+	resp, timeout = client.Execute(solrQuery)
+	if timeout || resp.Error != nil {
+		t.Errorf("Expected the job to succeed on retry, found %v", resp.Error)
+	}
+
+	if failures != 0 {
+		t.Errorf("Expected every failure to be consumed, %d left", failures)
+	}
+}
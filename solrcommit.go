@@ -0,0 +1,187 @@
+package gora
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/wirelessregistry/glog"
+)
+
+// CommitOptions sets when the changes of an update job become visible.
+// The zero value sends no commit, leaving it to Solr's autoCommit. A nil
+// *CommitOptions keeps the historical behaviour of the jobs, a hard
+// commit after every request.
+type CommitOptions struct {
+	// Commit sends a commit after the update
+	Commit bool
+
+	// SoftCommit makes the commit a soft commit, which opens a new
+	// searcher without flushing to stable storage
+	SoftCommit bool
+
+	// CommitWithin asks Solr to commit within this many milliseconds,
+	// instead of committing right away
+	CommitWithin int
+
+	// WaitSearcher, when false, returns before the new searcher is open
+	WaitSearcher *bool
+
+	// ExpungeDeletes merges away the segments with deleted documents
+	ExpungeDeletes bool
+}
+
+// NoCommit sends no commit with an update.
+func NoCommit() *CommitOptions {
+	return &CommitOptions{}
+}
+
+// Commit sends a hard commit after an update.
+func Commit() *CommitOptions {
+	return &CommitOptions{Commit: true}
+}
+
+// SoftCommit sends a soft commit after an update.
+func SoftCommit() *CommitOptions {
+	return &CommitOptions{Commit: true, SoftCommit: true}
+}
+
+// CommitWithin asks Solr to commit an update within ms milliseconds.
+func CommitWithin(ms int) *CommitOptions {
+	return &CommitOptions{CommitWithin: ms}
+}
+
+// NoWaitSearcher returns from the commit before the new searcher is open.
+func (c *CommitOptions) NoWaitSearcher() *CommitOptions {
+	wait := false
+	c.WaitSearcher = &wait
+	return c
+}
+
+// WithExpungeDeletes merges away the segments with deleted documents
+// on commit.
+func (c *CommitOptions) WithExpungeDeletes() *CommitOptions {
+	c.ExpungeDeletes = true
+	return c
+}
+
+// defaultCommit is used by the update jobs without CommitOptions.
+var defaultCommit = &CommitOptions{Commit: true}
+
+func (c *CommitOptions) orDefault() *CommitOptions {
+	if c == nil {
+		return defaultCommit
+	}
+
+	return c
+}
+
+// options returns the options of the commit command.
+func (c *CommitOptions) options() map[string]interface{} {
+	options := make(map[string]interface{}, 3)
+	if c.SoftCommit {
+		options["softCommit"] = true
+	}
+
+	if c.WaitSearcher != nil {
+		options["waitSearcher"] = *c.WaitSearcher
+	}
+
+	if c.ExpungeDeletes {
+		options["expungeDeletes"] = true
+	}
+
+	return options
+}
+
+// command renders the commit command to send after the update commands,
+// or "" if there is none.
+func (c *CommitOptions) command() string {
+	c = c.orDefault()
+	if !c.Commit && !c.SoftCommit {
+		return ""
+	}
+
+	b, _ := json.Marshal(c.options())
+	return fmt.Sprintf(`"commit": %s`, b)
+}
+
+// updateBody creates the body of an update request from its commands,
+// followed by the commit command, if any.
+func updateBody(commands string, commit *CommitOptions) []byte {
+	if cmd := commit.command(); len(cmd) > 0 {
+		return []byte(fmt.Sprintf("{%s, %s}", commands, cmd))
+	}
+
+	return []byte(fmt.Sprintf("{%s}", commands))
+}
+
+// SolrUpdateCommandQuery represents a SolrJob sending a single update
+// command without documents: a commit, an optimize or a rollback.
+type SolrUpdateCommandQuery struct {
+	Command  string
+	Options  map[string]interface{}
+	handler  string
+	resultCh chan *SolrResponse
+}
+
+func newSolrUpdateCommandQuery(command string, options map[string]interface{}) *SolrUpdateCommandQuery {
+	return &SolrUpdateCommandQuery{
+		Command:  command,
+		Options:  options,
+		handler:  "update",
+		resultCh: make(chan *SolrResponse, 1),
+	}
+}
+
+// NewSolrCommitQuery creates a job committing the pending updates. Of
+// its CommitOptions, CommitWithin is ignored; nil sends a hard commit.
+func NewSolrCommitQuery(commit *CommitOptions) *SolrUpdateCommandQuery {
+	return newSolrUpdateCommandQuery("commit", commit.orDefault().options())
+}
+
+// NewSolrOptimizeQuery creates a job merging the index down to at most
+// maxSegments segments, or to one if maxSegments is zero. Optimizing is
+// expensive, and rarely needed.
+func NewSolrOptimizeQuery(maxSegments int) *SolrUpdateCommandQuery {
+	options := make(map[string]interface{})
+	if maxSegments > 0 {
+		options["maxSegments"] = maxSegments
+	}
+
+	return newSolrUpdateCommandQuery("optimize", options)
+}
+
+// NewSolrRollbackQuery creates a job discarding the updates made since
+// the last commit. Solr doesn't support it in SolrCloud mode.
+func NewSolrRollbackQuery() *SolrUpdateCommandQuery {
+	return newSolrUpdateCommandQuery("rollback", map[string]interface{}{})
+}
+
+func (q *SolrUpdateCommandQuery) Handler() string {
+	return q.handler
+}
+
+func (q *SolrUpdateCommandQuery) ResultCh() chan *SolrResponse {
+	return q.resultCh
+}
+
+func (q *SolrUpdateCommandQuery) Wait() *SolrResponse {
+	return <-q.ResultCh()
+}
+
+func (q *SolrUpdateCommandQuery) GetRows() int {
+	return 0
+}
+
+func (q *SolrUpdateCommandQuery) GetStart() int {
+	return 0
+}
+
+func (q *SolrUpdateCommandQuery) Bytes() []byte {
+	b, err := json.Marshal(map[string]interface{}{q.Command: q.Options})
+	if err != nil {
+		glog.Error(err)
+	}
+
+	return b
+}
@@ -0,0 +1,88 @@
+package gora
+
+import (
+	"testing"
+)
+
+func TestCommitOptions(t *testing.T) {
+	doc := map[string]interface{}{"id": "1"}
+
+	tests := []struct {
+		commit   *CommitOptions
+		expected string
+	}{
+		{nil, `{"add":{"doc":{"id":"1"}}, "commit": {}}`},
+		{NoCommit(), `{"add":{"doc":{"id":"1"}}}`},
+		{Commit().WithExpungeDeletes(), `{"add":{"doc":{"id":"1"}}, "commit": {"expungeDeletes":true}}`},
+		{SoftCommit().NoWaitSearcher(), `{"add":{"doc":{"id":"1"}}, "commit": {"softCommit":true,"waitSearcher":false}}`},
+		{CommitWithin(500), `{"add":{"doc":{"id":"1"},"commitWithin":500}}`},
+	}
+
+	for _, test := range tests {
+		query := NewSolrUpdateQuery(doc)
+		query.Commit = test.commit
+
+		if b := string(query.Bytes()); b != test.expected {
+			t.Errorf("Expected %s. Got %s.", test.expected, b)
+		}
+	}
+}
+
+func TestCommitOptionsBatch(t *testing.T) {
+	docs := []map[string]interface{}{{"id": "1"}, {"id": "2"}}
+
+	query := NewSolrBatchUpdateQueryCommitWithin(1000, docs)
+	expected := `{"add":{"doc":{"id":"1"},"commitWithin":1000},"add":{"doc":{"id":"2"},"commitWithin":1000}}`
+	if b := string(query.Bytes()); b != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+
+	query.Commit = SoftCommit()
+	expected = `{"add":{"doc":{"id":"1"}},"add":{"doc":{"id":"2"}}, "commit": {"softCommit":true}}`
+	if b := string(query.Bytes()); b != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+
+	deleteQuery := NewSolrDeleteQuery("type_s:draft")
+	deleteQuery.Commit = CommitWithin(200)
+	expected = `{"delete":{"query":"type_s:draft","commitWithin":200}}`
+	if b := string(deleteQuery.Bytes()); b != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+
+	batchDelete := NewSolrBatchDeleteQuery([]string{"1", "2"})
+	batchDelete.Commit = NoCommit()
+	expected = `{"delete":["1","2"]}`
+	if b := string(batchDelete.Bytes()); b != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+
+	batchDelete.Commit = CommitWithin(200)
+	expected = `{"delete":{"id":"1","commitWithin":200},"delete":{"id":"2","commitWithin":200}}`
+	if b := string(batchDelete.Bytes()); b != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+}
+
+func TestUpdateCommandQueries(t *testing.T) {
+	tests := []struct {
+		query    *SolrUpdateCommandQuery
+		expected string
+	}{
+		{NewSolrCommitQuery(nil), `{"commit":{}}`},
+		{NewSolrCommitQuery(SoftCommit().NoWaitSearcher()), `{"commit":{"softCommit":true,"waitSearcher":false}}`},
+		{NewSolrOptimizeQuery(0), `{"optimize":{}}`},
+		{NewSolrOptimizeQuery(4), `{"optimize":{"maxSegments":4}}`},
+		{NewSolrRollbackQuery(), `{"rollback":{}}`},
+	}
+
+	for _, test := range tests {
+		if b := string(test.query.Bytes()); b != test.expected {
+			t.Errorf("Expected %s. Got %s.", test.expected, b)
+		}
+
+		if test.query.Handler() != "update" {
+			t.Errorf("Unexpected handler %s", test.query.Handler())
+		}
+	}
+}
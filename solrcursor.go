@@ -0,0 +1,169 @@
+package gora
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrCursorSort = errors.New("Cursor sort must include the unique key field")
+	ErrCursorRows = errors.New("Cursor rows must be positive")
+)
+
+// SolrCursor walks through every document matching a SolrQuery using
+// Solr's cursorMark deep paging, fetching Rows documents at a time.
+// Unlike Start/Rows paging, the cost of each page does not grow with
+// its depth. Typical use:
+//
+//	cursor, err := NewSolrCursor(ctx, client, query, "id")
+//	for cursor.Next() {
+//		doc := cursor.Doc()
+//	}
+//	err = cursor.Err()
+type SolrCursor struct {
+	ctx   context.Context
+	query *SolrQuery
+	exec  func(context.Context, *SolrQuery) *SolrResponse
+
+	docs     []map[string]interface{}
+	pos      int
+	numFound int
+	done     bool
+	err      error
+}
+
+// NewSolrCursor creates a SolrCursor that runs its queries on a SolrClient.
+// The query's Sort must end ties on the collection's unique key field.
+func NewSolrCursor(ctx context.Context, client SolrClient, q *SolrQuery, uniqueKey string) (*SolrCursor, error) {
+	exec := func(ctx context.Context, q *SolrQuery) *SolrResponse {
+		resp, _ := executeContext(ctx, client, q)
+		return resp
+	}
+
+	return newSolrCursor(ctx, exec, q, uniqueKey)
+}
+
+// NewPoolSolrCursor creates a SolrCursor that submits its queries to a Pool.
+// The query's Sort must end ties on the collection's unique key field.
+func NewPoolSolrCursor(ctx context.Context, p *Pool, q *SolrQuery, uniqueKey string) (*SolrCursor, error) {
+	exec := func(ctx context.Context, q *SolrQuery) *SolrResponse {
+		if err := p.SubmitContext(ctx, q); err != nil {
+			return &SolrResponse{Error: err}
+		}
+
+		return q.Wait()
+	}
+
+	return newSolrCursor(ctx, exec, q, uniqueKey)
+}
+
+func newSolrCursor(ctx context.Context, exec func(context.Context, *SolrQuery) *SolrResponse, q *SolrQuery, uniqueKey string) (*SolrCursor, error) {
+	if q.Rows <= 0 {
+		return nil, ErrCursorRows
+	}
+
+	if q.Sort == nil || !sortsOn(*q.Sort, uniqueKey) {
+		return nil, ErrCursorSort
+	}
+
+	// Solr refuses a start offset along with a cursor
+	q.Start = 0
+	q.CursorMark = "*"
+
+	return &SolrCursor{
+		ctx:   ctx,
+		query: q,
+		exec:  exec,
+	}, nil
+}
+
+// sortsOn reports whether a sort specification has a clause on field.
+func sortsOn(sort, field string) bool {
+	for _, clause := range strings.Split(sort, ",") {
+		parts := strings.Fields(clause)
+		if len(parts) > 0 && parts[0] == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next advances the cursor to the next document, fetching a new page
+// when needed. It returns false when all documents have been read, or
+// when an error occurred, in which case Err returns it.
+func (c *SolrCursor) Next() bool {
+	if c.pos < len(c.docs) {
+		c.pos++
+	}
+
+	for c.pos >= len(c.docs) {
+		if c.done || c.err != nil {
+			return false
+		}
+
+		c.fetch()
+	}
+
+	return true
+}
+
+// fetch runs the query for the next page of documents.
+func (c *SolrCursor) fetch() {
+	resp := c.exec(c.ctx, c.query)
+	if resp.Error != nil {
+		c.err = resp.Error
+		return
+	}
+
+	if resp.Response == nil {
+		c.err = ErrNoDocs
+		return
+	}
+
+	if len(resp.NextCursorMark) == 0 {
+		c.err = ErrBadResponseType
+		return
+	}
+
+	c.docs = resp.Response.Docs
+	c.pos = 0
+	c.numFound = resp.Response.NumFound
+
+	// An unchanged cursor mark means there is nothing left to read
+	c.done = resp.NextCursorMark == c.query.CursorMark
+	c.query.CursorMark = resp.NextCursorMark
+}
+
+// Doc returns the current document.
+func (c *SolrCursor) Doc() map[string]interface{} {
+	if c.pos >= len(c.docs) {
+		return nil
+	}
+
+	return c.docs[c.pos]
+}
+
+// DecodeDoc stores the current document in the struct pointed to by v.
+// See DecodeDocument.
+func (c *SolrCursor) DecodeDoc(v interface{}) error {
+	return DecodeDocument(c.Doc(), v)
+}
+
+// NumFound returns the total number of documents matching the query,
+// as reported by the last page.
+func (c *SolrCursor) NumFound() int {
+	return c.numFound
+}
+
+// CursorMark returns the mark of the next page, which can be used to
+// resume the walk later on.
+func (c *SolrCursor) CursorMark() string {
+	return c.query.CursorMark
+}
+
+// Err returns the error that stopped the cursor, if any.
+func (c *SolrCursor) Err() error {
+	return c.err
+}
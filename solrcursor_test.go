@@ -0,0 +1,99 @@
+package gora
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSolrCursor(t *testing.T) {
+	pages := map[string]string{
+		"*":    `{"responseHeader": {"status": 0, "QTime": 1}, "response": {"numFound": 3, "start": 0, "docs": [{"id": "1"}, {"id": "2"}]}, "nextCursorMark": "AoE1"}`,
+		"AoE1": `{"responseHeader": {"status": 0, "QTime": 1}, "response": {"numFound": 3, "start": 0, "docs": [{"id": "3"}]}, "nextCursorMark": "AoE2"}`,
+		"AoE2": `{"responseHeader": {"status": 0, "QTime": 1}, "response": {"numFound": 3, "start": 0, "docs": []}, "nextCursorMark": "AoE2"}`,
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Params map[string]interface{}
+		}
+		json.NewDecoder(r.Body).Decode(&query)
+
+		if query.Params["start"] != float64(0) {
+			http.Error(w, "start with cursorMark", http.StatusBadRequest)
+			return
+		}
+
+		page, ok := pages[fmt.Sprint(query.Params["cursorMark"])]
+		if !ok {
+			http.Error(w, "bad cursorMark", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, page)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewHttpSolrClient(server.URL, "core")
+
+	solrQuery := NewSolrQuery("*:*", 10, 2, nil, nil, nil, "select")
+	_, err := NewSolrCursor(context.Background(), client, solrQuery, "id")
+	if err != ErrCursorSort {
+		t.Errorf("Expected %v, got %v", ErrCursorSort, err)
+	}
+
+	sort := "score desc, id asc"
+	solrQuery = NewSolrQuery("*:*", 10, 2, nil, nil, &sort, "select")
+	cursor, err := NewSolrCursor(context.Background(), client, solrQuery, "id")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	var ids []string
+	for cursor.Next() {
+		var doc struct {
+			Id string `solr:"id"`
+		}
+
+		if err := cursor.DecodeDoc(&doc); err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+		ids = append(ids, doc.Id)
+	}
+
+	if cursor.Err() != nil {
+		t.Fatalf("Unexpected error %s", cursor.Err())
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("Unexpected documents %v", ids)
+	}
+
+	if cursor.NumFound() != 3 || cursor.CursorMark() != "AoE2" {
+		t.Errorf("Unexpected cursor state %d %s", cursor.NumFound(), cursor.CursorMark())
+	}
+
+	// The same walk through a pool, starting from a mark Solr doesn't know
+	p := NewPool([]SolrClient{client}, 1, 0, 1)
+	sig, _ := p.Run()
+	defer func() {
+		p.Stop()
+		<-sig
+	}()
+
+	solrQuery = NewSolrQuery("*:*", 0, 2, nil, nil, &sort, "select")
+	cursor, _ = NewPoolSolrCursor(context.Background(), p, solrQuery, "id")
+	solrQuery.CursorMark = "bad"
+
+	if cursor.Next() {
+		t.Error("Expected the cursor to stop")
+	}
+
+	if cursor.Err() == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -0,0 +1,160 @@
+package gora
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DebugInfo holds the "debug" section of a response, returned with
+// SolrQuery.Debug. Raw keeps the whole section, including what is not
+// decoded here.
+type DebugInfo struct {
+	RawQueryString    string
+	QueryString       string
+	ParsedQuery       string
+	ParsedQueryString string
+	QParser           string
+
+	FilterQueries       []string
+	ParsedFilterQueries []string
+
+	// Explain holds the score explanation of each document, by id
+	Explain map[string]*Explanation
+
+	// Timing holds the time spent in each search component
+	Timing *DebugTiming
+
+	Raw map[string]interface{}
+}
+
+// Explanation is the explanation of a score, or of a part of it. Text
+// is set when Solr returns the explanation as plain text, in which case
+// only the first line is decoded into Value and Description. Structured
+// explanations are returned with SolrQuery.ExplainStructured.
+type Explanation struct {
+	Match       bool
+	Value       float64
+	Description string
+	Details     []*Explanation
+	Text        string
+}
+
+// DebugTiming holds the time spent preparing and processing the request,
+// in milliseconds.
+type DebugTiming struct {
+	Time    float64
+	Prepare *PhaseTiming
+	Process *PhaseTiming
+}
+
+// PhaseTiming holds the time spent in each search component during one
+// phase of the request, in milliseconds.
+type PhaseTiming struct {
+	Time       float64
+	Components map[string]float64
+}
+
+// decodeDebug decodes the "debug" section of a response.
+func decodeDebug(section map[string]interface{}) *DebugInfo {
+	debug := &DebugInfo{Raw: section}
+
+	debug.RawQueryString, _ = section["rawquerystring"].(string)
+	debug.QueryString, _ = section["querystring"].(string)
+	debug.ParsedQuery, _ = section["parsedquery"].(string)
+	debug.ParsedQueryString, _ = section["parsedquery_toString"].(string)
+	debug.QParser, _ = section["QParser"].(string)
+	debug.FilterQueries = stringList(section["filter_queries"])
+	debug.ParsedFilterQueries = stringList(section["parsed_filter_queries"])
+
+	if explain := namedList(section["explain"]); explain != nil {
+		debug.Explain = make(map[string]*Explanation, len(explain))
+		for _, pair := range explain {
+			id, _ := pair[0].(string)
+			debug.Explain[id] = decodeExplanation(pair[1])
+		}
+	}
+
+	if timing, ok := section["timing"].(map[string]interface{}); ok {
+		debug.Timing = &DebugTiming{
+			Prepare: decodePhaseTiming(timing["prepare"]),
+			Process: decodePhaseTiming(timing["process"]),
+		}
+		debug.Timing.Time, _ = timing["time"].(float64)
+	}
+
+	return debug
+}
+
+func decodeExplanation(v interface{}) *Explanation {
+	switch e := v.(type) {
+	case string:
+		explanation := &Explanation{Text: e}
+
+		line := strings.TrimSpace(e)
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+
+		if i := strings.Index(line, " = "); i >= 0 {
+			if value, err := strconv.ParseFloat(line[:i], 64); err == nil {
+				explanation.Value = value
+				explanation.Match = true
+				explanation.Description = line[i+3:]
+			}
+		}
+
+		return explanation
+
+	case map[string]interface{}:
+		explanation := &Explanation{}
+		explanation.Match, _ = e["match"].(bool)
+		explanation.Value, _ = e["value"].(float64)
+		explanation.Description, _ = e["description"].(string)
+
+		details, _ := e["details"].([]interface{})
+		for _, d := range details {
+			explanation.Details = append(explanation.Details, decodeExplanation(d))
+		}
+
+		return explanation
+	}
+
+	return nil
+}
+
+func decodePhaseTiming(v interface{}) *PhaseTiming {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	phase := &PhaseTiming{Components: make(map[string]float64, len(m))}
+	for name, t := range m {
+		if name == "time" {
+			phase.Time, _ = t.(float64)
+			continue
+		}
+
+		if component, ok := t.(map[string]interface{}); ok {
+			phase.Components[name], _ = component["time"].(float64)
+		}
+	}
+
+	return phase
+}
+
+func stringList(v interface{}) []string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+
+	return strs
+}
@@ -0,0 +1,66 @@
+package gora
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDebugResponse(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {"numFound": 2, "start": 0, "docs": [{"id": "1"}, {"id": "2"}]},
+		"debug": {
+			"rawquerystring": "title:shoes",
+			"querystring": "title:shoes",
+			"parsedquery": "title:shoes",
+			"parsedquery_toString": "title:shoes",
+			"QParser": "LuceneQParser",
+			"filter_queries": ["inStock:true"],
+			"parsed_filter_queries": ["inStock:T"],
+			"explain": {
+				"1": "\n1.2 = weight(title:shoes in 0) [SchemaSimilarity], result of:\n  1.2 = score(freq=1.0)\n",
+				"2": {"match": true, "value": 0.8, "description": "weight(title:shoes in 1)",
+					"details": [{"match": true, "value": 0.8, "description": "score(freq=1.0)", "details": []}]}
+			},
+			"timing": {
+				"time": 3.0,
+				"prepare": {"time": 1.0, "query": {"time": 1.0}, "facet": {"time": 0.0}},
+				"process": {"time": 2.0, "query": {"time": 2.0}}
+			}
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	debug := resp.Debug
+	if debug == nil || debug.ParsedQuery != "title:shoes" || debug.QParser != "LuceneQParser" {
+		t.Fatalf("Unexpected debug %+v", debug)
+	}
+
+	if !reflect.DeepEqual(debug.ParsedFilterQueries, []string{"inStock:T"}) {
+		t.Errorf("Unexpected filter queries %v", debug.ParsedFilterQueries)
+	}
+
+	text := debug.Explain["1"]
+	if text == nil || text.Value != 1.2 || text.Description != "weight(title:shoes in 0) [SchemaSimilarity], result of:" {
+		t.Errorf("Unexpected explanation %+v", text)
+	}
+
+	structured := debug.Explain["2"]
+	if structured == nil || !structured.Match || structured.Value != 0.8 || len(structured.Details) != 1 ||
+		structured.Details[0].Description != "score(freq=1.0)" {
+		t.Errorf("Unexpected explanation %+v", structured)
+	}
+
+	timing := debug.Timing
+	if timing == nil || timing.Time != 3 || timing.Prepare.Time != 1 || timing.Process.Components["query"] != 2 {
+		t.Errorf("Unexpected timing %+v", timing)
+	}
+
+	if _, ok := timing.Prepare.Components["facet"]; !ok {
+		t.Errorf("Unexpected prepare timing %v", timing.Prepare.Components)
+	}
+}
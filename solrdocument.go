@@ -0,0 +1,448 @@
+package gora
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBadDecodeTarget = errors.New("Decode target must be a pointer to a slice of structs")
+	ErrBadDocument     = errors.New("Document must be a struct or a pointer to a struct")
+)
+
+// SolrDateFormat is the ISO-8601 layout Solr uses for date fields.
+const SolrDateFormat = "2006-01-02T15:04:05.999999999Z"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// docField describes how a struct field maps onto a Solr field.
+//
+// Fields are named by their `solr:"name,omitempty"` tag, or by the Go
+// field name if untagged. A tag of "-" skips the field. A name with a
+// leading or trailing "*" (e.g. `solr:"*_s"`) declares a dynamic field:
+// the struct field must be a map with string keys, and it holds every
+// Solr field whose name matches the pattern.
+type docField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	dynamic   bool
+}
+
+// match reports whether a Solr field name matches a dynamic field pattern.
+func (f *docField) match(name string) bool {
+	if strings.HasPrefix(f.name, "*") {
+		return strings.HasSuffix(name, f.name[1:])
+	}
+
+	return strings.HasPrefix(name, f.name[:len(f.name)-1])
+}
+
+var docFieldCache sync.Map
+
+// docFields returns the Solr fields of a struct type, including the
+// fields of embedded structs.
+func docFields(t reflect.Type) []docField {
+	if fields, ok := docFieldCache.Load(t); ok {
+		return fields.([]docField)
+	}
+
+	fields := make([]docField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("solr")
+		if tag == "-" {
+			continue
+		}
+
+		if sf.Anonymous && tag == "" && sf.Type.Kind() == reflect.Struct {
+			for _, f := range docFields(sf.Type) {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+
+		if len(sf.PkgPath) > 0 {
+			continue
+		}
+
+		f := docField{
+			name:  sf.Name,
+			index: []int{i},
+		}
+
+		parts := strings.Split(tag, ",")
+		if len(parts[0]) > 0 {
+			f.name = parts[0]
+		}
+
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+
+		f.dynamic = strings.HasPrefix(f.name, "*") || strings.HasSuffix(f.name, "*")
+		if f.dynamic && (sf.Type.Kind() != reflect.Map || sf.Type.Key().Kind() != reflect.String) {
+			continue
+		}
+
+		fields = append(fields, f)
+	}
+
+	docFieldCache.Store(t, fields)
+	return fields
+}
+
+// Decode stores the documents of a SolrResponse in the slice pointed
+// to by v. The slice elements must be structs or pointers to structs.
+func Decode(resp *SolrResponse, v interface{}) error {
+	if resp == nil || resp.Response == nil {
+		return ErrNoDocs
+	}
+
+	return DecodeDocuments(resp.Response.Docs, v)
+}
+
+// DecodeDocuments stores a list of Solr documents in the slice pointed
+// to by v. The slice elements must be structs or pointers to structs.
+func DecodeDocuments(docs []map[string]interface{}, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return ErrBadDecodeTarget
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return ErrBadDecodeTarget
+	}
+
+	out := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		elem := reflect.New(structType)
+		if err := decodeStruct(elem.Elem(), doc); err != nil {
+			return err
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			out = reflect.Append(out, elem)
+		} else {
+			out = reflect.Append(out, elem.Elem())
+		}
+	}
+
+	slice.Set(out)
+	return nil
+}
+
+// DecodeDocument stores a single Solr document in the struct pointed to by v.
+func DecodeDocument(doc map[string]interface{}, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrBadDocument
+	}
+
+	return decodeStruct(rv.Elem(), doc)
+}
+
+func decodeStruct(sv reflect.Value, doc map[string]interface{}) error {
+	fields := docFields(sv.Type())
+
+	for i := range fields {
+		f := &fields[i]
+		fv := sv.FieldByIndex(f.index)
+
+		if !f.dynamic {
+			raw, ok := doc[f.name]
+			if !ok {
+				continue
+			}
+
+			if err := decodeValue(fv, raw); err != nil {
+				return fmt.Errorf("Cannot decode field %s: %v", f.name, err)
+			}
+			continue
+		}
+
+		for name, raw := range doc {
+			if !f.match(name) {
+				continue
+			}
+
+			if fv.IsNil() {
+				fv.Set(reflect.MakeMap(fv.Type()))
+			}
+
+			value := reflect.New(fv.Type().Elem()).Elem()
+			if err := decodeValue(value, raw); err != nil {
+				return fmt.Errorf("Cannot decode field %s: %v", name, err)
+			}
+
+			fv.SetMapIndex(reflect.ValueOf(name).Convert(fv.Type().Key()), value)
+		}
+	}
+
+	return nil
+}
+
+// decodeValue converts a value produced by encoding/json into v.
+func decodeValue(v reflect.Value, raw interface{}) error {
+	if raw == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := decodeValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+
+		v.Set(elem)
+		return nil
+	}
+
+	if v.Kind() == reflect.Interface {
+		v.Set(reflect.ValueOf(raw))
+		return nil
+	}
+
+	// Multi-valued fields fill slices. A single value is accepted as a
+	// list of one, and a list of one as a single value.
+	list, isList := raw.([]interface{})
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		if !isList {
+			list = []interface{}{raw}
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := decodeValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+		return nil
+	}
+
+	if isList {
+		if len(list) != 1 {
+			return fmt.Errorf("%d values for a single valued %s", len(list), v.Type())
+		}
+
+		return decodeValue(v, list[0])
+	}
+
+	if v.Type() == timeType {
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("cannot use %T as a date", raw)
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		doc, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot use %T as %s", raw, v.Type())
+		}
+
+		return decodeStruct(v, doc)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// _version_ is decoded exactly, see decodeJSON
+		if i, ok := raw.(int64); ok {
+			v.SetInt(i)
+			return nil
+		}
+
+		n, ok := raw.(float64)
+		if !ok {
+			return fmt.Errorf("cannot use %T as %s", raw, v.Type())
+		}
+
+		v.SetInt(int64(n))
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i, ok := raw.(int64); ok && i >= 0 {
+			v.SetUint(uint64(i))
+			return nil
+		}
+
+		n, ok := raw.(float64)
+		if !ok || n < 0 {
+			return fmt.Errorf("cannot use %v as %s", raw, v.Type())
+		}
+
+		v.SetUint(uint64(n))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		n, ok := raw.(float64)
+		if !ok {
+			return fmt.Errorf("cannot use %T as %s", raw, v.Type())
+		}
+
+		v.SetFloat(n)
+		return nil
+	}
+
+	rv := reflect.ValueOf(raw)
+	if !rv.Type().ConvertibleTo(v.Type()) || rv.Kind() != v.Kind() {
+		return fmt.Errorf("cannot use %T as %s", raw, v.Type())
+	}
+
+	v.Set(rv.Convert(v.Type()))
+	return nil
+}
+
+// EncodeDocument converts a struct, or a pointer to one, into a Solr
+// document using the struct's `solr` tags. Dates are formatted in UTC
+// as Solr expects them, and nil pointers are sent as null unless the
+// field is tagged omitempty.
+func EncodeDocument(v interface{}) (map[string]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, ErrBadDocument
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct || rv.Type() == timeType {
+		return nil, ErrBadDocument
+	}
+
+	return encodeStruct(rv)
+}
+
+func encodeStruct(sv reflect.Value) (map[string]interface{}, error) {
+	fields := docFields(sv.Type())
+	doc := make(map[string]interface{}, len(fields))
+
+	for i := range fields {
+		f := &fields[i]
+		fv := sv.FieldByIndex(f.index)
+
+		if !f.dynamic {
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+
+			value, err := encodeValue(fv)
+			if err != nil {
+				return nil, fmt.Errorf("Cannot encode field %s: %v", f.name, err)
+			}
+
+			doc[f.name] = value
+			continue
+		}
+
+		iter := fv.MapRange()
+		for iter.Next() {
+			name := iter.Key().String()
+			if !f.match(name) {
+				return nil, fmt.Errorf("Field %s does not match dynamic field %s", name, f.name)
+			}
+
+			if f.omitEmpty && isEmptyValue(iter.Value()) {
+				continue
+			}
+
+			value, err := encodeValue(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("Cannot encode field %s: %v", name, err)
+			}
+
+			doc[name] = value
+		}
+	}
+
+	return doc, nil
+}
+
+func encodeValue(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		return encodeValue(v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+
+		// leave []byte to encoding/json
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), nil
+		}
+
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			value, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+
+			list[i] = value
+		}
+
+		return list, nil
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).UTC().Format(SolrDateFormat), nil
+		}
+
+		return encodeStruct(v)
+	}
+
+	return v.Interface(), nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package gora
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testBase struct {
+	Id string `solr:"id"`
+}
+
+type testDoc struct {
+	testBase
+	Greeting string            `solr:"greeting"`
+	Tags     []string          `solr:"tags_ss,omitempty"`
+	Count    int               `solr:"count_i"`
+	Price    *float64          `solr:"price_f,omitempty"`
+	Parent   *string           `solr:"parent_s"`
+	Created  time.Time         `solr:"created_dt"`
+	Extra    map[string]string `solr:"*_s"`
+	Version  int64             `solr:"_version_,omitempty"`
+	Ignored  string            `solr:"-"`
+}
+
+func TestDecodeDocuments(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {
+			"numFound": 2,
+			"start": 0,
+			"docs": [
+				{
+					"id": "one",
+					"greeting": ["你好"],
+					"tags_ss": ["a", "b"],
+					"count_i": 3,
+					"price_f": 1.5,
+					"created_dt": "2016-01-02T03:04:05.123Z",
+					"color_s": "red",
+					"size_s": "xl"
+				},
+				{
+					"id": "two",
+					"tags_ss": "c",
+					"parent_s": "one"
+				}
+			]
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	var docs []testDoc
+	if err := Decode(resp, &docs); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 documents, found %d", len(docs))
+	}
+
+	created := time.Date(2016, 1, 2, 3, 4, 5, 123000000, time.UTC)
+	price := 1.5
+	expected := testDoc{
+		testBase: testBase{Id: "one"},
+		Greeting: "你好",
+		Tags:     []string{"a", "b"},
+		Count:    3,
+		Price:    &price,
+		Created:  created,
+		Extra:    map[string]string{"color_s": "red", "size_s": "xl"},
+	}
+
+	if !reflect.DeepEqual(docs[0], expected) {
+		t.Errorf("Unexpected document %+v", docs[0])
+	}
+
+	if docs[1].Parent == nil || *docs[1].Parent != "one" || !reflect.DeepEqual(docs[1].Tags, []string{"c"}) {
+		t.Errorf("Unexpected document %+v", docs[1])
+	}
+
+	var ptrs []*testDoc
+	if err := Decode(resp, &ptrs); err != nil || len(ptrs) != 2 || ptrs[1].Id != "two" {
+		t.Errorf("Unexpected decoding to pointers %v %v", ptrs, err)
+	}
+
+	if err := Decode(resp, docs); err != ErrBadDecodeTarget {
+		t.Errorf("Expected %v, got %v", ErrBadDecodeTarget, err)
+	}
+
+	var bad []struct {
+		Count string `solr:"count_i"`
+	}
+	if err := Decode(resp, &bad); err == nil {
+		t.Error("Expected a type mismatch error")
+	}
+}
+
+func TestSolrUpdateQueryFromStruct(t *testing.T) {
+	expected := []byte(`{"add":{"doc":{"color_s":"red","count_i":3,"created_dt":"2016-01-02T03:04:05.123Z","greeting":"你好","id":"one","parent_s":null}}, "commit": {}}`)
+
+	doc := testDoc{
+		testBase: testBase{Id: "one"},
+		Greeting: "你好",
+		Count:    3,
+		Created:  time.Date(2016, 1, 2, 4, 4, 5, 123000000, time.FixedZone("CET", 3600)),
+		Extra:    map[string]string{"color_s": "red"},
+		Ignored:  "ignored",
+	}
+
+	solrQuery, err := NewSolrUpdateQueryFromStruct(&doc)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if bytes.Compare(expected, solrQuery.Bytes()) != 0 {
+		t.Errorf("Found unexpected query data: %s", solrQuery.Bytes())
+	}
+
+	doc.Extra["color"] = "blue"
+	if _, err := NewSolrUpdateQueryFromStruct(doc); err == nil {
+		t.Error("Expected an error for a field outside of the dynamic field")
+	}
+
+	if _, err := NewSolrUpdateQueryFromStruct("doc"); err != ErrBadDocument {
+		t.Errorf("Expected %v, got %v", ErrBadDocument, err)
+	}
+}
+
+func TestSolrBatchUpdateQueryFromStructs(t *testing.T) {
+	expected := []byte(`{"add":{"doc":{"count_i":0,"created_dt":"0001-01-01T00:00:00Z","greeting":"","id":"one","parent_s":null,"tags_ss":["a","b"]}},"add":{"doc":{"count_i":0,"created_dt":"0001-01-01T00:00:00Z","greeting":"","id":"two","parent_s":null}}, "commit": {}}`)
+
+	docs := []testDoc{
+		{testBase: testBase{Id: "one"}, Tags: []string{"a", "b"}},
+		{testBase: testBase{Id: "two"}},
+	}
+
+	solrQuery, err := NewSolrBatchUpdateQueryFromStructs(docs)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if bytes.Compare(expected, solrQuery.Bytes()) != 0 {
+		t.Errorf("Found unexpected query data: %s", solrQuery.Bytes())
+	}
+}
@@ -0,0 +1,130 @@
+package gora
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SolrError represents an error reported by a Solr server, either in the
+// "error" section of a response or by an unsuccessful HTTP status.
+// Use errors.As to tell, say, a bad query (400) from a missing
+// collection (404) or a server fault (5xx).
+type SolrError struct {
+	// HTTPStatus is the status code of the HTTP response, if known
+	HTTPStatus int
+
+	// Code is Solr's error.code, usually equal to HTTPStatus
+	Code int
+
+	// Msg is Solr's error.msg
+	Msg string
+
+	// ErrorClass and RootErrorClass are the Java exception classes
+	// found in error.metadata
+	ErrorClass     string
+	RootErrorClass string
+
+	// Metadata holds every name/value pair found in error.metadata
+	Metadata map[string]string
+
+	// Trace is Solr's error.trace, only sent for server side faults
+	Trace string
+
+	// Handler and URL identify the request that produced the error
+	Handler string
+	URL     string
+}
+
+func (e *SolrError) Error() string {
+	if len(e.Msg) > 0 {
+		return e.Msg
+	}
+
+	status := e.HTTPStatus
+	if status == 0 {
+		status = e.Code
+	}
+
+	return fmt.Sprintf("Solr error %d: %s", status, http.StatusText(status))
+}
+
+// VersionConflictError is the SolrError of an update rejected because
+// a document's _version_ did not match the expected one (HTTP 409). The
+// document should be read again before retrying the update.
+type VersionConflictError struct {
+	*SolrError
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return e.SolrError
+}
+
+// StreamInterruptedError is the failure of a streaming job after some
+// of its documents were handed to its callback. Such a job is never
+// retried, since the callback would get those documents again.
+type StreamInterruptedError struct {
+	// Docs is the number of documents handed to the callback
+	Docs int
+	Err  error
+}
+
+func (e *StreamInterruptedError) Error() string {
+	return fmt.Sprintf("Stream interrupted after %d documents: %v", e.Docs, e.Err)
+}
+
+func (e *StreamInterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// statusError returns the error to report for a SolrError, which is a
+// VersionConflictError for a version conflict.
+func statusError(e *SolrError) error {
+	if e.Code == http.StatusConflict || e.HTTPStatus == http.StatusConflict {
+		return &VersionConflictError{e}
+	}
+
+	return e
+}
+
+// newSolrError decodes the "error" section of a Solr response. The status
+// from the response header is used when error.code is missing.
+func newSolrError(errMap map[string]interface{}, status int) *SolrError {
+	e := &SolrError{
+		Code: status,
+	}
+
+	if code, ok := errMap["code"].(float64); ok {
+		e.Code = int(code)
+	}
+
+	if msg, ok := errMap["msg"].(string); ok {
+		e.Msg = msg
+	}
+
+	if trace, ok := errMap["trace"].(string); ok {
+		e.Trace = trace
+	}
+
+	// metadata is a flat name/value list by default, or an object with json.nl=map
+	switch metadata := errMap["metadata"].(type) {
+	case []interface{}:
+		e.Metadata = make(map[string]string, len(metadata)/2)
+		for i := 0; i+1 < len(metadata); i += 2 {
+			name, _ := metadata[i].(string)
+			value, _ := metadata[i+1].(string)
+			e.Metadata[name] = value
+		}
+
+	case map[string]interface{}:
+		e.Metadata = make(map[string]string, len(metadata))
+		for name, v := range metadata {
+			value, _ := v.(string)
+			e.Metadata[name] = value
+		}
+	}
+
+	e.ErrorClass = e.Metadata["error-class"]
+	e.RootErrorClass = e.Metadata["root-error-class"]
+
+	return e
+}
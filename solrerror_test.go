@@ -0,0 +1,73 @@
+package gora
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSolrErrorFromResponse(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {
+			"status": 400,
+			"QTime": 1
+		},
+		"error": {
+			"metadata": [
+				"error-class", "org.apache.solr.common.SolrException",
+				"root-error-class", "org.apache.solr.parser.ParseException"
+			],
+			"msg": "org.apache.solr.search.SyntaxError: Cannot parse 'id:'",
+			"code": 400
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) {
+		t.Fatalf("Expected a SolrError, got %v", resp.Error)
+	}
+
+	if solrErr.Code != 400 {
+		t.Errorf("Expected code 400, got %d", solrErr.Code)
+	}
+
+	if solrErr.ErrorClass != "org.apache.solr.common.SolrException" {
+		t.Errorf("Unexpected error class %s", solrErr.ErrorClass)
+	}
+
+	if solrErr.RootErrorClass != "org.apache.solr.parser.ParseException" {
+		t.Errorf("Unexpected root error class %s", solrErr.RootErrorClass)
+	}
+
+	if solrErr.Error() != "org.apache.solr.search.SyntaxError: Cannot parse 'id:'" {
+		t.Errorf("Unexpected message %s", solrErr)
+	}
+}
+
+func TestSolrErrorWithoutMsg(t *testing.T) {
+	errMap := map[string]interface{}{
+		"metadata": map[string]interface{}{"error-class": "java.lang.NullPointerException"},
+		"trace":    "java.lang.NullPointerException\n\tat ...",
+	}
+
+	solrErr := newSolrError(errMap, 500)
+	if solrErr.Code != 500 {
+		t.Errorf("Expected code 500, got %d", solrErr.Code)
+	}
+
+	if solrErr.ErrorClass != "java.lang.NullPointerException" {
+		t.Errorf("Unexpected error class %s", solrErr.ErrorClass)
+	}
+
+	if len(solrErr.Trace) == 0 {
+		t.Error("Expected a trace")
+	}
+
+	if solrErr.Error() != "Solr error 500: Internal Server Error" {
+		t.Errorf("Unexpected message %s", solrErr)
+	}
+}
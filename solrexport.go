@@ -0,0 +1,359 @@
+package gora
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrExportFields = errors.New("Export query requires at least one field")
+	ErrExportSort   = errors.New("Export query requires a sort")
+	ErrExportFilter = errors.New("Export query filters must not be empty")
+)
+
+// SolrExportQuery represents a SolrJob for the /export handler, which
+// streams every document matching a query. Solr requires the fields and
+// the sort to be set, and every field involved to have docValues.
+//
+// When run by a SolrClient or a Pool, its documents are handed to
+// OnDocument as they are decoded. HttpSolrClient.Export reads them
+// one at a time instead.
+type SolrExportQuery struct {
+	Query   string
+	Fields  []string
+	Sort    string
+	Filters []string
+
+	// OnDocument receives the exported documents, see SolrStreamingJob
+	OnDocument func(map[string]interface{}) error
+
+	handler  string
+	resultCh chan *SolrResponse
+}
+
+// NewSolrExportQuery creates a SolrExportQuery. An empty q matches
+// every document.
+func NewSolrExportQuery(q string, fields []string, sort string, filters []string) (*SolrExportQuery, error) {
+	if len(fields) == 0 {
+		return nil, ErrExportFields
+	}
+
+	if len(strings.TrimSpace(sort)) == 0 {
+		return nil, ErrExportSort
+	}
+
+	for _, fq := range filters {
+		if len(strings.TrimSpace(fq)) == 0 {
+			return nil, ErrExportFilter
+		}
+	}
+
+	if len(q) == 0 {
+		q = "*:*"
+	}
+
+	return &SolrExportQuery{
+		Query:    q,
+		Fields:   fields,
+		Sort:     sort,
+		Filters:  filters,
+		handler:  "export",
+		resultCh: make(chan *SolrResponse, 1),
+	}, nil
+}
+
+func (q *SolrExportQuery) Handler() string {
+	return q.handler
+}
+
+func (q *SolrExportQuery) ResultCh() chan *SolrResponse {
+	return q.resultCh
+}
+
+func (q *SolrExportQuery) Wait() *SolrResponse {
+	return <-q.ResultCh()
+}
+
+func (q *SolrExportQuery) GetRows() int {
+	return 0
+}
+
+func (q *SolrExportQuery) GetStart() int {
+	return 0
+}
+
+func (q *SolrExportQuery) DocumentHandler() func(map[string]interface{}) error {
+	onDoc := q.OnDocument
+	if onDoc == nil {
+		onDoc = func(map[string]interface{}) error { return nil }
+	}
+
+	return func(doc map[string]interface{}) error {
+		if err := exportError(doc); err != nil {
+			return err
+		}
+
+		return onDoc(doc)
+	}
+}
+
+func (q *SolrExportQuery) Bytes() []byte {
+	query := make(map[string]interface{})
+
+	query["query"] = q.Query
+	query["fields"] = q.Fields
+	query["sort"] = q.Sort
+
+	if len(q.Filters) > 0 {
+		query["filter"] = q.Filters
+	}
+
+	query["params"] = map[string]interface{}{"wt": "json"}
+
+	b, _ := json.Marshal(query)
+	return b
+}
+
+// exportError detects the exception Solr writes in place of a document
+// when an export fails after the response has started.
+func exportError(doc map[string]interface{}) error {
+	exception, ok := doc["EXCEPTION"]
+	if !ok {
+		return nil
+	}
+
+	return &SolrError{
+		Code: http.StatusInternalServerError,
+		Msg:  fmt.Sprint(exception),
+	}
+}
+
+// SolrExportReader reads the documents of an /export response one at a
+// time. It must be closed once done with.
+type SolrExportReader struct {
+	body     io.ReadCloser
+	dec      *json.Decoder
+	doc      map[string]interface{}
+	numFound int
+	done     bool
+	err      error
+}
+
+// Export runs a SolrExportQuery and returns a reader over its documents.
+// Errors reported before the first document are returned right away;
+// later ones, including exceptions Solr emits mid-stream, by the reader's Err.
+func (c *HttpSolrClient) Export(ctx context.Context, q *SolrExportQuery) (*SolrExportReader, error) {
+	r, err := c.post(ctx, q.Handler(), nil, "application/json", q.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if r.StatusCode >= 400 {
+		defer r.Body.Close()
+
+		solrErr := &SolrError{Code: r.StatusCode}
+		body, _ := ioutil.ReadAll(r.Body)
+		if resp, err := SolrResponseFromHTTPResponse(body); err == nil {
+			errors.As(resp.Error, &solrErr)
+		}
+
+		return nil, c.solrError(solrErr, q.Handler(), r.StatusCode)
+	}
+
+	reader := &SolrExportReader{
+		body: r.Body,
+		dec:  newDecoder(r.Body),
+	}
+
+	if err := reader.start(); err != nil {
+		r.Body.Close()
+
+		if solrErr, ok := err.(*SolrError); ok {
+			c.solrError(solrErr, q.Handler(), r.StatusCode)
+		}
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// start reads the response up to its first document.
+func (r *SolrExportReader) start() error {
+	if err := expectDelim(r.dec, '{'); err != nil {
+		return err
+	}
+
+	for r.dec.More() {
+		key, err := objectKey(r.dec)
+		if err != nil {
+			return err
+		}
+
+		if key != "response" {
+			var value interface{}
+			if err := decodeJSON(r.dec, &value); err != nil {
+				return err
+			}
+
+			if err := exportHeaderError(key, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(r.dec, '{'); err != nil {
+			return err
+		}
+
+		for r.dec.More() {
+			key, err := objectKey(r.dec)
+			if err != nil {
+				return err
+			}
+
+			if key == "docs" {
+				return expectDelim(r.dec, '[')
+			}
+
+			var value interface{}
+			if err := decodeJSON(r.dec, &value); err != nil {
+				return err
+			}
+
+			if n, ok := value.(float64); ok && key == "numFound" {
+				r.numFound = int(n)
+			}
+		}
+
+		return ErrNoDocs
+	}
+
+	return ErrNoDocs
+}
+
+// exportHeaderError detects a failure reported in the response header.
+func exportHeaderError(key string, value interface{}) error {
+	if key != "responseHeader" {
+		return nil
+	}
+
+	header, ok := value.(map[string]interface{})
+	if !ok {
+		return ErrInvalidHeader
+	}
+
+	status, _ := header["status"].(float64)
+	if status == 0 {
+		return nil
+	}
+
+	return &SolrError{Code: int(status)}
+}
+
+// Next advances to the next document. It returns false at the end of
+// the export, or when an error occurred, in which case Err returns it.
+func (r *SolrExportReader) Next() bool {
+	if r.done {
+		return false
+	}
+
+	if !r.dec.More() {
+		// A connection dropped mid-stream also ends the list of docs
+		if err := r.finish(); err != nil {
+			r.fail(err)
+			return false
+		}
+
+		r.done = true
+		r.doc = nil
+		return false
+	}
+
+	var doc map[string]interface{}
+	if err := decodeJSON(r.dec, &doc); err != nil {
+		r.fail(err)
+		return false
+	}
+
+	if err := exportError(doc); err != nil {
+		r.fail(err)
+		return false
+	}
+
+	// streaming expressions end with an EOF tuple
+	if _, ok := doc["EOF"]; ok {
+		r.done = true
+		r.doc = nil
+		return false
+	}
+
+	r.doc = doc
+	return true
+}
+
+// finish reads the response past its last document.
+func (r *SolrExportReader) finish() error {
+	if err := expectDelim(r.dec, ']'); err != nil {
+		return err
+	}
+
+	if err := expectDelim(r.dec, '}'); err != nil {
+		return err
+	}
+
+	for r.dec.More() {
+		key, err := objectKey(r.dec)
+		if err != nil {
+			return err
+		}
+
+		var value interface{}
+		if err := decodeJSON(r.dec, &value); err != nil {
+			return err
+		}
+
+		if err := exportHeaderError(key, value); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(r.dec, '}')
+}
+
+func (r *SolrExportReader) fail(err error) {
+	r.err = err
+	r.done = true
+	r.doc = nil
+}
+
+// Doc returns the current document.
+func (r *SolrExportReader) Doc() map[string]interface{} {
+	return r.doc
+}
+
+// DecodeDoc stores the current document in the struct pointed to by v.
+// See DecodeDocument.
+func (r *SolrExportReader) DecodeDoc(v interface{}) error {
+	return DecodeDocument(r.doc, v)
+}
+
+// NumFound returns the number of documents being exported.
+func (r *SolrExportReader) NumFound() int {
+	return r.numFound
+}
+
+// Err returns the error that stopped the reader, if any.
+func (r *SolrExportReader) Err() error {
+	return r.err
+}
+
+// Close releases the underlying HTTP response.
+func (r *SolrExportReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,158 @@
+package gora
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSolrExportQuery(t *testing.T) {
+	_, err := NewSolrExportQuery("", nil, "id asc", nil)
+	if err != ErrExportFields {
+		t.Errorf("Expected %v, got %v", ErrExportFields, err)
+	}
+
+	_, err = NewSolrExportQuery("", []string{"id"}, " ", nil)
+	if err != ErrExportSort {
+		t.Errorf("Expected %v, got %v", ErrExportSort, err)
+	}
+
+	_, err = NewSolrExportQuery("", []string{"id"}, "id asc", []string{""})
+	if err != ErrExportFilter {
+		t.Errorf("Expected %v, got %v", ErrExportFilter, err)
+	}
+
+	query, err := NewSolrExportQuery("", []string{"id", "name_s"}, "id asc", []string{"type_s:a"})
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"query":  "*:*",
+		"fields": []interface{}{"id", "name_s"},
+		"sort":   "id asc",
+		"filter": []interface{}{"type_s:a"},
+		"params": map[string]interface{}{"wt": "json"},
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(query.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Error("Result was unexpected ", result)
+	}
+}
+
+func TestExport(t *testing.T) {
+	expected := bytes.NewBufferString(`{
+		"responseHeader": {"status": 0},
+		"response": {
+			"numFound": 2,
+			"docs": [{"id": "1"}, {"id": "2"}]
+		}
+	}`)
+
+	server, client := createTestServer(expected, "/export")
+	defer server.Close()
+
+	query, _ := NewSolrExportQuery("", []string{"id"}, "id asc", nil)
+	reader, err := client.Export(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	defer reader.Close()
+
+	var ids []interface{}
+	for reader.Next() {
+		ids = append(ids, reader.Doc()["id"])
+	}
+
+	if reader.Err() != nil {
+		t.Errorf("Unexpected error %s", reader.Err())
+	}
+
+	if reader.NumFound() != 2 || len(ids) != 2 {
+		t.Errorf("Unexpected documents %v of %d", ids, reader.NumFound())
+	}
+
+	// The same export through Execute
+	n := 0
+	query.OnDocument = func(doc map[string]interface{}) error {
+		n++
+		return nil
+	}
+
+	resp, _ := client.Execute(query)
+	if resp.Error != nil || n != 2 {
+		t.Errorf("Expected 2 documents, got %d. %v", n, resp.Error)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	expected := bytes.NewBufferString(`{
+		"responseHeader": {"status": 0},
+		"response": {
+			"numFound": 3,
+			"docs": [{"id": "1"}, {"EXCEPTION": "java.io.IOException: field has no docValues"}]
+		}
+	}`)
+
+	server, client := createTestServer(expected, "/export")
+	defer server.Close()
+
+	query, _ := NewSolrExportQuery("", []string{"id"}, "id asc", nil)
+	reader, err := client.Export(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	defer reader.Close()
+
+	n := 0
+	for reader.Next() {
+		n++
+	}
+
+	var solrErr *SolrError
+	if n != 1 || !errors.As(reader.Err(), &solrErr) {
+		t.Fatalf("Expected a SolrError after 1 document, got %v after %d", reader.Err(), n)
+	}
+
+	if solrErr.Msg != "java.io.IOException: field has no docValues" {
+		t.Errorf("Unexpected message %s", solrErr.Msg)
+	}
+
+	resp, _ := client.Execute(query)
+	if !errors.As(resp.Error, &solrErr) {
+		t.Errorf("Expected a SolrError, got %v", resp.Error)
+	}
+}
+
+func TestExportTruncated(t *testing.T) {
+	expected := bytes.NewBufferString(`{
+		"responseHeader": {"status": 0},
+		"response": {
+			"numFound": 3,
+			"docs": [{"id": "1"}`)
+
+	server, client := createTestServer(expected, "/export")
+	defer server.Close()
+
+	query, _ := NewSolrExportQuery("", []string{"id"}, "id asc", nil)
+	reader, err := client.Export(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	defer reader.Close()
+
+	for reader.Next() {
+	}
+
+	if reader.Err() == nil {
+		t.Error("Expected an error for a truncated export")
+	}
+}
@@ -0,0 +1,351 @@
+package gora
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONFacet is an entry of a JSON Facet API request: either a Facet,
+// or an Aggregation computed over the enclosing bucket.
+type JSONFacet interface {
+	json.Marshaler
+}
+
+// Facet is a terms, range, query or heatmap facet of the JSON Facet API.
+// Its setters return the facet itself so that calls can be chained:
+//
+//	TermsFacet("category").Limit(5).Sort("avg_price desc").
+//		SubFacet("avg_price", Avg("price"))
+type Facet struct {
+	params map[string]interface{}
+	facets map[string]JSONFacet
+	domain *FacetDomain
+}
+
+func newFacet(facetType string) *Facet {
+	return &Facet{
+		params: map[string]interface{}{"type": facetType},
+	}
+}
+
+// TermsFacet buckets documents by the values of a field.
+func TermsFacet(field string) *Facet {
+	return newFacet("terms").Set("field", field)
+}
+
+// RangeFacet buckets documents by ranges of a numeric or date field.
+// start, end and gap are numbers, or date math strings for date fields.
+func RangeFacet(field string, start, end, gap interface{}) *Facet {
+	return newFacet("range").Set("field", field).Set("start", start).Set("end", end).Set("gap", gap)
+}
+
+// QueryFacet creates a single bucket of the documents matching a query.
+func QueryFacet(q string) *Facet {
+	return newFacet("query").Set("q", q)
+}
+
+// HeatmapFacet counts documents of a spatial field over a grid covering geom.
+func HeatmapFacet(field, geom string) *Facet {
+	return newFacet("heatmap").Set("field", field).Set("geom", geom)
+}
+
+// Set sets any facet parameter, for options without a dedicated setter.
+func (f *Facet) Set(param string, value interface{}) *Facet {
+	f.params[param] = value
+	return f
+}
+
+func (f *Facet) Limit(n int) *Facet {
+	return f.Set("limit", n)
+}
+
+func (f *Facet) Offset(n int) *Facet {
+	return f.Set("offset", n)
+}
+
+func (f *Facet) MinCount(n int) *Facet {
+	return f.Set("mincount", n)
+}
+
+// Sort orders the buckets, e.g. "count desc", "index asc" or by a
+// sub-facet aggregation such as "avg_price desc".
+func (f *Facet) Sort(sort string) *Facet {
+	return f.Set("sort", sort)
+}
+
+func (f *Facet) Prefix(prefix string) *Facet {
+	return f.Set("prefix", prefix)
+}
+
+// Missing adds a bucket for the documents without a value.
+func (f *Facet) Missing(missing bool) *Facet {
+	return f.Set("missing", missing)
+}
+
+// NumBuckets requests the number of buckets, ignoring limit and offset.
+func (f *Facet) NumBuckets(numBuckets bool) *Facet {
+	return f.Set("numBuckets", numBuckets)
+}
+
+// AllBuckets adds a bucket aggregating all the buckets.
+func (f *Facet) AllBuckets(allBuckets bool) *Facet {
+	return f.Set("allBuckets", allBuckets)
+}
+
+// Other adds range buckets outside of start and end: before, after,
+// between, all or none.
+func (f *Facet) Other(other ...string) *Facet {
+	return f.Set("other", other)
+}
+
+func (f *Facet) HardEnd(hardEnd bool) *Facet {
+	return f.Set("hardend", hardEnd)
+}
+
+// GridLevel sets the precision of a heatmap facet.
+func (f *Facet) GridLevel(level int) *Facet {
+	return f.Set("gridLevel", level)
+}
+
+// SubFacet adds a facet or aggregation computed for every bucket.
+func (f *Facet) SubFacet(name string, sub JSONFacet) *Facet {
+	if f.facets == nil {
+		f.facets = make(map[string]JSONFacet)
+	}
+
+	f.facets[name] = sub
+	return f
+}
+
+// Domain changes the documents the facet is computed over.
+func (f *Facet) Domain(domain *FacetDomain) *Facet {
+	f.domain = domain
+	return f
+}
+
+func (f *Facet) MarshalJSON() ([]byte, error) {
+	facet := make(map[string]interface{}, len(f.params)+2)
+	for k, v := range f.params {
+		facet[k] = v
+	}
+
+	if len(f.facets) > 0 {
+		facet["facet"] = f.facets
+	}
+
+	if f.domain != nil {
+		facet["domain"] = f.domain
+	}
+
+	return json.Marshal(facet)
+}
+
+// FacetDomain changes the domain of a facet, i.e. the documents it is
+// computed over, relative to its parent bucket.
+type FacetDomain struct {
+	// ExcludeTags ignores the filters with these tags
+	ExcludeTags []string `json:"excludeTags,omitempty"`
+
+	// BlockParent maps child documents to the parents matching this query
+	BlockParent string `json:"blockParent,omitempty"`
+
+	// BlockChildren maps parent documents, matching this query, to their children
+	BlockChildren string `json:"blockChildren,omitempty"`
+
+	// Join maps documents to the documents sharing a field value
+	Join *FacetJoin `json:"join,omitempty"`
+
+	// Query replaces the domain, Filter narrows it down
+	Query  []string `json:"query,omitempty"`
+	Filter []string `json:"filter,omitempty"`
+}
+
+// FacetJoin maps the documents of a domain to the documents whose
+// To field matches their From field.
+type FacetJoin struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Aggregation is a JSON Facet API function computed over the documents
+// of a bucket, e.g. Sum("price") or Percentile("price", 50, 99).
+type Aggregation string
+
+func (a Aggregation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(a))
+}
+
+func aggregation(function, field string) Aggregation {
+	return Aggregation(fmt.Sprintf("%s(%s)", function, field))
+}
+
+func Sum(field string) Aggregation {
+	return aggregation("sum", field)
+}
+
+func Avg(field string) Aggregation {
+	return aggregation("avg", field)
+}
+
+func Min(field string) Aggregation {
+	return aggregation("min", field)
+}
+
+func Max(field string) Aggregation {
+	return aggregation("max", field)
+}
+
+func SumSq(field string) Aggregation {
+	return aggregation("sumsq", field)
+}
+
+func Variance(field string) Aggregation {
+	return aggregation("variance", field)
+}
+
+func Stddev(field string) Aggregation {
+	return aggregation("stddev", field)
+}
+
+// Unique counts the distinct values of a field exactly.
+func Unique(field string) Aggregation {
+	return aggregation("unique", field)
+}
+
+// HLL estimates the distinct values of a field with HyperLogLog.
+func HLL(field string) Aggregation {
+	return aggregation("hll", field)
+}
+
+// Percentile estimates the given percentiles of a field.
+func Percentile(field string, percentiles ...float64) Aggregation {
+	args := make([]string, 0, len(percentiles)+1)
+	args = append(args, field)
+	for _, p := range percentiles {
+		args = append(args, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+
+	return aggregation("percentile", strings.Join(args, ","))
+}
+
+// FacetResult is a decoded JSON Facet API result: the root of the
+// "facets" section, a facet, or one of its buckets. Sub-facets and
+// aggregations are found under the names they were requested with.
+type FacetResult struct {
+	// Count is the number of documents in the domain or bucket
+	Count int
+
+	// Val is the value of a bucket
+	Val interface{}
+
+	// Buckets holds the buckets of a terms or range facet
+	Buckets []*FacetResult
+
+	// Missing, AllBuckets and NumBuckets are set when requested
+	Missing    *FacetResult
+	AllBuckets *FacetResult
+	NumBuckets int
+
+	// Facets holds the sub-facets, by name
+	Facets map[string]*FacetResult
+
+	// Stats holds the aggregations, and any other raw value, by name
+	Stats map[string]interface{}
+}
+
+// newFacetResult decodes a facet, or a bucket, of the "facets" section.
+func newFacetResult(m map[string]interface{}) *FacetResult {
+	r := &FacetResult{}
+
+	for k, v := range m {
+		switch k {
+		case "count":
+			if n, ok := v.(float64); ok {
+				r.Count = int(n)
+				continue
+			}
+
+		case "val":
+			r.Val = v
+			continue
+
+		case "numBuckets":
+			if n, ok := v.(float64); ok {
+				r.NumBuckets = int(n)
+				continue
+			}
+
+		case "buckets":
+			if buckets, ok := v.([]interface{}); ok {
+				r.Buckets = make([]*FacetResult, 0, len(buckets))
+				for _, b := range buckets {
+					if bucket, ok := b.(map[string]interface{}); ok {
+						r.Buckets = append(r.Buckets, newFacetResult(bucket))
+					}
+				}
+				continue
+			}
+
+		case "missing", "allBuckets":
+			if bucket, ok := v.(map[string]interface{}); ok {
+				if k == "missing" {
+					r.Missing = newFacetResult(bucket)
+				} else {
+					r.AllBuckets = newFacetResult(bucket)
+				}
+				continue
+			}
+		}
+
+		if facet, ok := v.(map[string]interface{}); ok {
+			if r.Facets == nil {
+				r.Facets = make(map[string]*FacetResult)
+			}
+			r.Facets[k] = newFacetResult(facet)
+			continue
+		}
+
+		if r.Stats == nil {
+			r.Stats = make(map[string]interface{})
+		}
+		r.Stats[k] = v
+	}
+
+	return r
+}
+
+// Facet returns the sub-facet with the given name, or nil.
+func (r *FacetResult) Facet(name string) *FacetResult {
+	if r == nil {
+		return nil
+	}
+
+	return r.Facets[name]
+}
+
+// Stat returns a numeric aggregation by name.
+func (r *FacetResult) Stat(name string) (float64, bool) {
+	if r == nil {
+		return 0, false
+	}
+
+	n, ok := r.Stats[name].(float64)
+	return n, ok
+}
+
+// Bucket returns the bucket whose value prints as val, or nil.
+func (r *FacetResult) Bucket(val string) *FacetResult {
+	if r == nil {
+		return nil
+	}
+
+	for _, b := range r.Buckets {
+		if fmt.Sprint(b.Val) == val {
+			return b
+		}
+	}
+
+	return nil
+}
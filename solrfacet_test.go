@@ -0,0 +1,122 @@
+package gora
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONFacets(t *testing.T) {
+	solrQuery := NewSolrQuery("*:*", 0, 0, nil, nil, nil, "select")
+	solrQuery.AddFacet("categories", TermsFacet("cat_s").Limit(5).MinCount(1).Sort("avg_price desc").
+		SubFacet("avg_price", Avg("price_f")).
+		SubFacet("p", Percentile("price_f", 50, 99.9)).
+		Domain(&FacetDomain{ExcludeTags: []string{"cat"}}))
+	solrQuery.AddFacet("prices", RangeFacet("price_f", 0, 100, 20).Other("after").
+		SubFacet("sellers", HLL("seller_s")))
+	solrQuery.AddFacet("cheap", QueryFacet("price_f:[* TO 10]").
+		Domain(&FacetDomain{BlockChildren: "type_s:product", Join: &FacetJoin{From: "id", To: "product_s"}}))
+	solrQuery.AddFacet("map", HeatmapFacet("location_p", "[\"-180 -90\" TO \"180 90\"]").GridLevel(2))
+	solrQuery.AddFacet("revenue", Sum("price_f"))
+	solrQuery.AddFacet("sellers", Unique("seller_s"))
+
+	expected := `{
+		"categories": {
+			"type": "terms", "field": "cat_s", "limit": 5, "mincount": 1, "sort": "avg_price desc",
+			"facet": {"avg_price": "avg(price_f)", "p": "percentile(price_f,50,99.9)"},
+			"domain": {"excludeTags": ["cat"]}
+		},
+		"prices": {
+			"type": "range", "field": "price_f", "start": 0, "end": 100, "gap": 20, "other": ["after"],
+			"facet": {"sellers": "hll(seller_s)"}
+		},
+		"cheap": {
+			"type": "query", "q": "price_f:[* TO 10]",
+			"domain": {"blockChildren": "type_s:product", "join": {"from": "id", "to": "product_s"}}
+		},
+		"map": {"type": "heatmap", "field": "location_p", "geom": "[\"-180 -90\" TO \"180 90\"]", "gridLevel": 2},
+		"revenue": "sum(price_f)",
+		"sellers": "unique(seller_s)"
+	}`
+
+	var result, expectedFacets map[string]interface{}
+	if err := json.Unmarshal(solrQuery.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if err := json.Unmarshal([]byte(expected), &expectedFacets); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result["facet"], expectedFacets) {
+		t.Error("Result was unexpected ", result["facet"])
+	}
+}
+
+func TestFacetResult(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"facets": {
+			"count": 42,
+			"revenue": 1234.5,
+			"categories": {
+				"numBuckets": 7,
+				"allBuckets": {"count": 40},
+				"missing": {"count": 2},
+				"buckets": [
+					{"val": "books", "count": 30, "avg_price": 12.5, "p": [9.5, 40.0],
+					 "sellers": {"buckets": [{"val": "acme", "count": 20}]}},
+					{"val": 3, "count": 10, "avg_price": 7.0}
+				]
+			},
+			"cheap": {"count": 12, "sellers": 4}
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if resp.JSONFacets.Count != 42 {
+		t.Errorf("Expected a count of 42, got %d", resp.JSONFacets.Count)
+	}
+
+	if revenue, ok := resp.JSONFacets.Stat("revenue"); !ok || revenue != 1234.5 {
+		t.Errorf("Unexpected revenue %v", revenue)
+	}
+
+	categories := resp.Facet("categories")
+	if categories == nil || len(categories.Buckets) != 2 {
+		t.Fatalf("Unexpected categories %+v", categories)
+	}
+
+	if categories.NumBuckets != 7 || categories.AllBuckets.Count != 40 || categories.Missing.Count != 2 {
+		t.Errorf("Unexpected categories %+v", categories)
+	}
+
+	books := categories.Bucket("books")
+	if avg, _ := books.Stat("avg_price"); books.Count != 30 || avg != 12.5 {
+		t.Errorf("Unexpected bucket %+v", books)
+	}
+
+	if books.Facet("sellers").Bucket("acme").Count != 20 {
+		t.Errorf("Unexpected sub-facet %+v", books.Facet("sellers"))
+	}
+
+	if !reflect.DeepEqual(books.Stats["p"], []interface{}{9.5, 40.0}) {
+		t.Errorf("Unexpected percentiles %v", books.Stats["p"])
+	}
+
+	if categories.Bucket("3") == nil {
+		t.Error("Expected a numeric bucket")
+	}
+
+	if sellers, _ := resp.Facet("cheap").Stat("sellers"); resp.Facet("cheap").Count != 12 || sellers != 4 {
+		t.Errorf("Unexpected query facet %+v", resp.Facet("cheap"))
+	}
+
+	if resp.Facet("unknown").Facet("unknown") != nil {
+		t.Error("Expected no facet")
+	}
+}
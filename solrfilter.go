@@ -0,0 +1,95 @@
+package gora
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FilterQuery is a filter query (fq) of a SolrQuery. Tags name the
+// filter so that facets can exclude it. Filters are cached unless Cache
+// is false; an uncached filter with a Cost of 100 or more is run as a
+// post filter, after the main query and the cheaper filters.
+type FilterQuery struct {
+	Query string
+	Tags  []string
+	Cache *bool
+	Cost  int
+}
+
+// NewFilterQuery creates a FilterQuery. Query may start with its own
+// local params, e.g. {!geofilt sfield=location}.
+func NewFilterQuery(fq string) *FilterQuery {
+	return &FilterQuery{Query: fq}
+}
+
+// Tag adds tags to the filter.
+func (f *FilterQuery) Tag(tags ...string) *FilterQuery {
+	f.Tags = append(f.Tags, tags...)
+	return f
+}
+
+// NoCache keeps the filter out of the filter cache.
+func (f *FilterQuery) NoCache() *FilterQuery {
+	cache := false
+	f.Cache = &cache
+	return f
+}
+
+// WithCost sets the order in which uncached filters are run.
+func (f *FilterQuery) WithCost(cost int) *FilterQuery {
+	f.Cost = cost
+	return f
+}
+
+// String renders the filter with its options as local params, merged
+// into the query's own local params if it has any.
+func (f *FilterQuery) String() string {
+	params := make([]string, 0, 3)
+	if len(f.Tags) > 0 {
+		params = append(params, "tag="+localParamValue(strings.Join(f.Tags, ",")))
+	}
+
+	if f.Cache != nil {
+		params = append(params, "cache="+strconv.FormatBool(*f.Cache))
+	}
+
+	if f.Cost != 0 {
+		params = append(params, "cost="+strconv.Itoa(f.Cost))
+	}
+
+	if len(params) == 0 {
+		return f.Query
+	}
+
+	if end := localParamsEnd(f.Query); end > 0 {
+		return f.Query[:end] + " " + strings.Join(params, " ") + f.Query[end:]
+	}
+
+	return "{!" + strings.Join(params, " ") + "}" + f.Query
+}
+
+// localParamsEnd returns the index of the "}" closing the local params
+// a query starts with, or -1.
+func localParamsEnd(q string) int {
+	if !strings.HasPrefix(q, "{!") {
+		return -1
+	}
+
+	var quote byte
+	for i := 2; i < len(q); i++ {
+		switch c := q[i]; {
+		case c == '\\' && quote != 0:
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+
+	return -1
+}
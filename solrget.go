@@ -0,0 +1,89 @@
+package gora
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/wirelessregistry/glog"
+)
+
+// SolrRealtimeGetQuery represents a SolrJob for the real-time get handler,
+// reading documents by id, including the updates not yet committed. The
+// documents found are returned in SolrResponse.Response, whether one or
+// several ids were requested.
+type SolrRealtimeGetQuery struct {
+	Ids []string
+
+	// Fields lists the fields to return (fl)
+	Fields []string
+
+	// Filters drop the documents they don't match (fq)
+	Filters []string
+
+	Params   map[string]interface{}
+	handler  string
+	resultCh chan *SolrResponse
+}
+
+// NewSolrRealtimeGetQuery creates a SolrRealtimeGetQuery for the /get
+// handler.
+func NewSolrRealtimeGetQuery(ids ...string) *SolrRealtimeGetQuery {
+	params := make(map[string]interface{})
+	params["wt"] = "json"
+
+	return &SolrRealtimeGetQuery{
+		Ids:      ids,
+		Params:   params,
+		handler:  "get",
+		resultCh: make(chan *SolrResponse, 1),
+	}
+}
+
+func (q *SolrRealtimeGetQuery) Handler() string {
+	return q.handler
+}
+
+func (q *SolrRealtimeGetQuery) ResultCh() chan *SolrResponse {
+	return q.resultCh
+}
+
+func (q *SolrRealtimeGetQuery) Wait() *SolrResponse {
+	return <-q.ResultCh()
+}
+
+func (q *SolrRealtimeGetQuery) GetRows() int {
+	return len(q.Ids)
+}
+
+func (q *SolrRealtimeGetQuery) GetStart() int {
+	return 0
+}
+
+func (q *SolrRealtimeGetQuery) Bytes() []byte {
+	params := make(map[string]interface{}, len(q.Params)+3)
+	for k, v := range q.Params {
+		params[k] = v
+	}
+
+	// a single id gets the "doc" response shape, a list the "response" one
+	if len(q.Ids) == 1 {
+		params["id"] = q.Ids[0]
+	} else {
+		params["id"] = q.Ids
+	}
+
+	if len(q.Fields) > 0 {
+		params["fl"] = strings.Join(q.Fields, ",")
+	}
+
+	if len(q.Filters) > 0 {
+		params["fq"] = q.Filters
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"params": params})
+	if err != nil {
+		glog.Error(err)
+	}
+
+	return b
+}
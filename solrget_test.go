@@ -0,0 +1,89 @@
+package gora
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSolrRealtimeGetQuery(t *testing.T) {
+	query := NewSolrRealtimeGetQuery("1")
+	query.Fields = []string{"id", "title"}
+	query.Filters = []string{"inStock:true"}
+
+	var result struct {
+		Params map[string]interface{}
+	}
+	if err := json.Unmarshal(query.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	expected := map[string]interface{}{
+		"wt": "json",
+		"id": "1",
+		"fl": "id,title",
+		"fq": []interface{}{"inStock:true"},
+	}
+	if !reflect.DeepEqual(result.Params, expected) {
+		t.Error("Result was unexpected ", result.Params)
+	}
+
+	query = NewSolrRealtimeGetQuery("1", "a,b")
+	result.Params = nil
+	if err := json.Unmarshal(query.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result.Params["id"], []interface{}{"1", "a,b"}) {
+		t.Error("Result was unexpected ", result.Params)
+	}
+
+	if query.Handler() != "get" || query.GetRows() != 2 {
+		t.Errorf("Unexpected job %s %d", query.Handler(), query.GetRows())
+	}
+}
+
+func TestRealtimeGetResponse(t *testing.T) {
+	resp, err := SolrResponseFromHTTPResponse([]byte(`{"doc": {"id": "1", "title": "uncommitted"}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if resp.Status != 0 || resp.Response.NumFound != 1 || resp.Response.Docs[0]["title"] != "uncommitted" {
+		t.Errorf("Unexpected response %+v", resp.Response)
+	}
+
+	resp, err = SolrResponseFromHTTPResponse([]byte(`{"doc": null}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if resp.Response.NumFound != 0 || len(resp.Response.Docs) != 0 {
+		t.Errorf("Unexpected response %+v", resp.Response)
+	}
+
+	resp, err = SolrResponseFromHTTPResponse([]byte(`{"response": {"numFound": 2, "start": 0, "docs": [{"id": "1"}, {"id": "2"}]}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if resp.Response.NumFound != 2 || len(resp.Response.Docs) != 2 {
+		t.Errorf("Unexpected response %+v", resp.Response)
+	}
+}
+
+func TestRealtimeGetQuery(t *testing.T) {
+	expected := bytes.NewBufferString(`{"doc": {"id": "1"}}`)
+	server, client := createTestServer(expected, "/get")
+	defer server.Close()
+
+	resp, retry := client.Execute(NewSolrRealtimeGetQuery("1"))
+	if retry || resp.Error != nil {
+		t.Fatalf("Unexpected error %v", resp.Error)
+	}
+
+	if resp.Response.Docs[0]["id"] != "1" {
+		t.Errorf("Unexpected documents %v", resp.Response.Docs)
+	}
+}
@@ -0,0 +1,244 @@
+package gora
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GroupOptions configures result grouping on a SolrQuery. Zero values
+// leave Solr's defaults in place.
+type GroupOptions struct {
+	// Fields and Queries define the groups; each gets its own entry
+	// in SolrResponse.Grouped
+	Fields  []string
+	Queries []string
+
+	// Limit and Offset select the documents returned per group
+	Limit  int
+	Offset int
+
+	// Sort orders the documents within each group
+	Sort string
+
+	// NGroups requests the number of groups
+	NGroups bool
+
+	// Truncate computes facets on the most relevant document of each group
+	Truncate bool
+
+	// Facet computes grouped facets
+	Facet bool
+}
+
+func (g *GroupOptions) addParams(params map[string]interface{}) {
+	params["group"] = true
+
+	if len(g.Fields) > 0 {
+		params["group.field"] = g.Fields
+	}
+
+	if len(g.Queries) > 0 {
+		params["group.query"] = g.Queries
+	}
+
+	if g.Limit != 0 {
+		params["group.limit"] = g.Limit
+	}
+
+	if g.Offset > 0 {
+		params["group.offset"] = g.Offset
+	}
+
+	if len(g.Sort) > 0 {
+		params["group.sort"] = g.Sort
+	}
+
+	if g.NGroups {
+		params["group.ngroups"] = true
+	}
+
+	if g.Truncate {
+		params["group.truncate"] = true
+	}
+
+	if g.Facet {
+		params["group.facet"] = true
+	}
+}
+
+// CollapseOptions collapses the results to one document per value of
+// Field, sent as a {!collapse} filter. By default the highest scoring
+// document of each group is kept; Min, Max or Sort pick another one.
+type CollapseOptions struct {
+	Field string
+
+	// Min or Max keep the document with the lowest or highest value of
+	// a field or function
+	Min string
+	Max string
+
+	// Sort keeps the first document of each group in this order
+	Sort string
+
+	// NullPolicy is "ignore", "expand" or "collapse"
+	NullPolicy string
+
+	// Hint may be "top_fc" for string fields
+	Hint string
+
+	// Size is the initial size of the collapse data structures
+	Size int
+}
+
+func (c *CollapseOptions) String() string {
+	params := []string{"collapse", "field=" + localParamValue(c.Field)}
+
+	if len(c.Min) > 0 {
+		params = append(params, "min="+localParamValue(c.Min))
+	}
+
+	if len(c.Max) > 0 {
+		params = append(params, "max="+localParamValue(c.Max))
+	}
+
+	if len(c.Sort) > 0 {
+		params = append(params, "sort="+localParamValue(c.Sort))
+	}
+
+	if len(c.NullPolicy) > 0 {
+		params = append(params, "nullPolicy="+localParamValue(c.NullPolicy))
+	}
+
+	if len(c.Hint) > 0 {
+		params = append(params, "hint="+localParamValue(c.Hint))
+	}
+
+	if c.Size > 0 {
+		params = append(params, "size="+strconv.Itoa(c.Size))
+	}
+
+	return "{!" + strings.Join(params, " ") + "}"
+}
+
+// ExpandOptions returns the documents collapsed by a CollapseOptions
+// filter, see SolrResponse.Expanded.
+type ExpandOptions struct {
+	// Rows is the number of documents returned per group
+	Rows int
+
+	// Sort orders the documents within each group
+	Sort string
+
+	// Query and Filter replace the main query and filters when
+	// selecting the expanded documents
+	Query  string
+	Filter string
+}
+
+func (e *ExpandOptions) addParams(params map[string]interface{}) {
+	params["expand"] = true
+
+	if e.Rows > 0 {
+		params["expand.rows"] = e.Rows
+	}
+
+	if len(e.Sort) > 0 {
+		params["expand.sort"] = e.Sort
+	}
+
+	if len(e.Query) > 0 {
+		params["expand.q"] = e.Query
+	}
+
+	if len(e.Filter) > 0 {
+		params["expand.fq"] = e.Filter
+	}
+}
+
+// GroupResult is the result of grouping by a field or by a query.
+type GroupResult struct {
+	// Matches is the number of documents matching the query
+	Matches int
+
+	// NGroups is set when GroupOptions.NGroups was requested
+	NGroups int
+
+	// Groups holds the groups of a field
+	Groups []*Group
+
+	// DocList holds the documents of a query
+	DocList *DocumentCollection
+}
+
+// Group is a group of documents sharing a field value.
+type Group struct {
+	GroupValue interface{}
+	DocList    *DocumentCollection
+}
+
+// Group returns the group whose value prints as value, or nil.
+func (r *GroupResult) Group(value string) *Group {
+	if r == nil {
+		return nil
+	}
+
+	for _, g := range r.Groups {
+		if fmt.Sprint(g.GroupValue) == value {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// decodeGrouped decodes the "grouped" section of a response.
+func decodeGrouped(section map[string]interface{}) (map[string]*GroupResult, error) {
+	grouped := make(map[string]*GroupResult, len(section))
+
+	for name, v := range section {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, ErrBadResponseType
+		}
+
+		result := &GroupResult{}
+		if matches, ok := m["matches"].(float64); ok {
+			result.Matches = int(matches)
+		}
+
+		if ngroups, ok := m["ngroups"].(float64); ok {
+			result.NGroups = int(ngroups)
+		}
+
+		if doclist, ok := m["doclist"]; ok {
+			coll, err := newDocumentCollection(doclist)
+			if err != nil {
+				return nil, err
+			}
+			result.DocList = coll
+		}
+
+		groups, _ := m["groups"].([]interface{})
+		for _, g := range groups {
+			groupMap, ok := g.(map[string]interface{})
+			if !ok {
+				return nil, ErrBadResponseType
+			}
+
+			coll, err := newDocumentCollection(groupMap["doclist"])
+			if err != nil {
+				return nil, err
+			}
+
+			result.Groups = append(result.Groups, &Group{
+				GroupValue: groupMap["groupValue"],
+				DocList:    coll,
+			})
+		}
+
+		grouped[name] = result
+	}
+
+	return grouped, nil
+}
@@ -0,0 +1,107 @@
+package gora
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGroupingOptions(t *testing.T) {
+	solrQuery := NewSolrQuery("shoes", 0, 10, nil, nil, nil, "select")
+	solrQuery.Group = &GroupOptions{
+		Fields:  []string{"seller_s"},
+		Queries: []string{"price_f:[0 TO 10]"},
+		Limit:   3,
+		Sort:    "price_f asc",
+		NGroups: true,
+	}
+	solrQuery.Collapse = &CollapseOptions{Field: "product_s", Max: "sum(sales_i,views_i)", NullPolicy: "expand"}
+	solrQuery.Expand = &ExpandOptions{Rows: 5, Sort: "price_f asc"}
+
+	expectedParams := map[string]interface{}{
+		"wt":            "json",
+		"start":         float64(0),
+		"rows":          float64(10),
+		"group":         true,
+		"group.field":   []interface{}{"seller_s"},
+		"group.query":   []interface{}{"price_f:[0 TO 10]"},
+		"group.limit":   float64(3),
+		"group.sort":    "price_f asc",
+		"group.ngroups": true,
+		"expand":        true,
+		"expand.rows":   float64(5),
+		"expand.sort":   "price_f asc",
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(solrQuery.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result["params"], expectedParams) {
+		t.Error("Result was unexpected ", result["params"])
+	}
+
+	expectedFilter := []interface{}{"{!collapse field=product_s max=sum(sales_i,views_i) nullPolicy=expand}"}
+	if !reflect.DeepEqual(result["filter"], expectedFilter) {
+		t.Error("Result was unexpected ", result["filter"])
+	}
+}
+
+func TestGroupedResponse(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"grouped": {
+			"seller_s": {
+				"matches": 12,
+				"ngroups": 2,
+				"groups": [
+					{"groupValue": "acme", "doclist": {"numFound": 8, "start": 0, "docs": [{"id": "1"}, {"id": "2"}]}},
+					{"groupValue": null, "doclist": {"numFound": 4, "start": 0, "docs": [{"id": "3"}]}}
+				]
+			},
+			"price_f:[0 TO 10]": {
+				"matches": 12,
+				"doclist": {"numFound": 5, "start": 0, "docs": [{"id": "4"}]}
+			}
+		},
+		"expanded": {
+			"p1": {"numFound": 2, "start": 0, "docs": [{"id": "5"}, {"id": "6"}]}
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if resp.Response != nil {
+		t.Error("Expected no main document list")
+	}
+
+	sellers := resp.Grouped["seller_s"]
+	if sellers.Matches != 12 || sellers.NGroups != 2 || len(sellers.Groups) != 2 {
+		t.Fatalf("Unexpected groups %+v", sellers)
+	}
+
+	if acme := sellers.Group("acme"); acme.DocList.NumFound != 8 || len(acme.DocList.Docs) != 2 {
+		t.Errorf("Unexpected group %+v", acme)
+	}
+
+	if sellers.Groups[1].GroupValue != nil {
+		t.Errorf("Expected a null group value, got %v", sellers.Groups[1].GroupValue)
+	}
+
+	if cheap := resp.Grouped["price_f:[0 TO 10]"]; cheap.DocList.NumFound != 5 {
+		t.Errorf("Unexpected query group %+v", cheap)
+	}
+
+	if p1 := resp.Expanded["p1"]; p1 == nil || p1.NumFound != 2 || len(p1.Docs) != 2 {
+		t.Errorf("Unexpected expanded documents %+v", p1)
+	}
+
+	raw = []byte(`{"responseHeader": {"status": 0, "QTime": 1}, "grouped": {"seller_s": {"groups": [{"groupValue": "a"}]}}}`)
+	if _, err := SolrResponseFromHTTPResponse(raw); err != ErrBadResponseType {
+		t.Errorf("Expected %v, got %v", ErrBadResponseType, err)
+	}
+}
@@ -0,0 +1,114 @@
+package gora
+
+import (
+	"sort"
+	"strings"
+)
+
+// HighlightOptions configures the highlighting of a SolrQuery.
+// Zero values leave Solr's defaults in place.
+type HighlightOptions struct {
+	// Method is the highlighter: "unified", "original" or "fastVector"
+	Method string
+
+	// Fields lists the fields to highlight (hl.fl)
+	Fields []string
+
+	// Query is the query to highlight, if not the main one (hl.q)
+	Query string
+
+	// FragSize is the approximate size of a snippet, in characters
+	FragSize int
+
+	// Snippets is the maximum number of snippets per field
+	Snippets int
+
+	// PreTag and PostTag surround the highlighted terms
+	PreTag  string
+	PostTag string
+
+	// RequireFieldMatch only highlights terms matched on the same field
+	RequireFieldMatch bool
+}
+
+func (h *HighlightOptions) addParams(params map[string]interface{}) {
+	params["hl"] = true
+
+	if len(h.Method) > 0 {
+		params["hl.method"] = h.Method
+	}
+
+	if len(h.Fields) > 0 {
+		params["hl.fl"] = strings.Join(h.Fields, ",")
+	}
+
+	if len(h.Query) > 0 {
+		params["hl.q"] = h.Query
+	}
+
+	if h.FragSize > 0 {
+		params["hl.fragsize"] = h.FragSize
+	}
+
+	if h.Snippets > 0 {
+		params["hl.snippets"] = h.Snippets
+	}
+
+	if len(h.PreTag) > 0 {
+		params["hl.tag.pre"] = h.PreTag
+	}
+
+	if len(h.PostTag) > 0 {
+		params["hl.tag.post"] = h.PostTag
+	}
+
+	if h.RequireFieldMatch {
+		params["hl.requireFieldMatch"] = true
+	}
+}
+
+// Transformer renders a document transformer for SolrQuery.Fields,
+// e.g. Transformer("explain", map[string]string{"style": "nl"}) gives
+// "[explain style=nl]".
+func Transformer(name string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(params)+1)
+	args = append(args, name)
+	for _, k := range keys {
+		args = append(args, k+"="+localParamValue(params[k]))
+	}
+
+	return "[" + strings.Join(args, " ") + "]"
+}
+
+// decodeHighlighting decodes the "highlighting" section of a response,
+// keyed by document id, then by field.
+func decodeHighlighting(section map[string]interface{}) map[string]map[string][]string {
+	highlighting := make(map[string]map[string][]string, len(section))
+
+	for id, fields := range section {
+		fieldMap, ok := fields.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		snippets := make(map[string][]string, len(fieldMap))
+		for field, list := range fieldMap {
+			items, _ := list.([]interface{})
+			for _, item := range items {
+				if snippet, ok := item.(string); ok {
+					snippets[field] = append(snippets[field], snippet)
+				}
+			}
+		}
+
+		highlighting[id] = snippets
+	}
+
+	return highlighting
+}
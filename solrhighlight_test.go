@@ -0,0 +1,81 @@
+package gora
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestResponseShapingOptions(t *testing.T) {
+	solrQuery := NewSolrQuery("title:shoes", 0, 10, nil, nil, nil, "select")
+	solrQuery.Fields = []string{"id", "name:name_s", "score", Transformer("explain", map[string]string{"style": "nl"})}
+	solrQuery.Debug = []string{"timing", "query"}
+	solrQuery.TimeAllowed = 500
+	solrQuery.Highlight = &HighlightOptions{
+		Method:   "unified",
+		Fields:   []string{"title", "body"},
+		FragSize: 80,
+		Snippets: 2,
+		PreTag:   "<em>",
+		PostTag:  "</em>",
+	}
+	solrQuery.Params["hl.snippets"] = 3
+
+	expectedParams := map[string]interface{}{
+		"wt":          "json",
+		"start":       float64(0),
+		"rows":        float64(10),
+		"debug":       []interface{}{"timing", "query"},
+		"timeAllowed": float64(500),
+		"hl":          true,
+		"hl.method":   "unified",
+		"hl.fl":       "title,body",
+		"hl.fragsize": float64(80),
+		"hl.snippets": float64(3),
+		"hl.tag.pre":  "<em>",
+		"hl.tag.post": "</em>",
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(solrQuery.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result["params"], expectedParams) {
+		t.Error("Result was unexpected ", result["params"])
+	}
+
+	expectedFields := []interface{}{"id", "name:name_s", "score", "[explain style=nl]"}
+	if !reflect.DeepEqual(result["fields"], expectedFields) {
+		t.Error("Result was unexpected ", result["fields"])
+	}
+}
+
+func TestHighlighting(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1, "partialResults": true},
+		"response": {"numFound": 1, "start": 0, "docs": [{"id": "1"}]},
+		"highlighting": {
+			"1": {"title": ["red <em>shoes</em>"], "body": ["<em>shoes</em> for", "all <em>shoes</em>"]},
+			"2": {}
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	expected := map[string]map[string][]string{
+		"1": {"title": {"red <em>shoes</em>"}, "body": {"<em>shoes</em> for", "all <em>shoes</em>"}},
+		"2": {},
+	}
+
+	if !reflect.DeepEqual(resp.Highlighting, expected) {
+		t.Errorf("Unexpected highlighting %v", resp.Highlighting)
+	}
+
+	if !resp.PartialResults {
+		t.Error("Expected partial results")
+	}
+}
@@ -1,5 +1,9 @@
 package gora
 
+import (
+	"net/url"
+)
+
 // SolrJob is the interface that a SolrClient needs in order
 // to get enough information to connnect to a Solr server.
 type SolrJob interface {
@@ -19,3 +23,33 @@ type SolrJob interface {
 	GetRows() int
 	GetStart() int
 }
+
+// SolrStreamingJob is a SolrJob whose documents are handed to a callback
+// one at a time while the response is decoded, instead of being collected
+// in SolrResponse.Response.Docs. This keeps memory flat for large result
+// sets. A nil callback disables streaming.
+type SolrStreamingJob interface {
+	SolrJob
+
+	// DocumentHandler returns the callback that receives each document.
+	// An error returned by the callback stops decoding, and is placed
+	// in the job's SolrResponse.
+	DocumentHandler() func(map[string]interface{}) error
+}
+
+// SolrParamsJob is a SolrJob with request parameters that are sent in
+// the query string of the URL rather than in its body.
+type SolrParamsJob interface {
+	SolrJob
+
+	// URLParams returns the parameters added to the handler's URL
+	URLParams() url.Values
+}
+
+// SolrContentTypeJob is a SolrJob whose Bytes are not JSON.
+type SolrContentTypeJob interface {
+	SolrJob
+
+	// ContentType returns the content type of Bytes
+	ContentType() string
+}
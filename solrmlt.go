@@ -0,0 +1,245 @@
+package gora
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/wirelessregistry/glog"
+)
+
+// SolrMoreLikeThisQuery represents a SolrJob for the MoreLikeThis handler,
+// finding the documents similar to an indexed document, or to a text.
+// The similar documents are returned in SolrResponse.Response, the
+// document matched by id in SolrResponse.Match.
+type SolrMoreLikeThisQuery struct {
+	// Query selects the document to compare to; see NewSolrMoreLikeThisQuery
+	Query string
+
+	// Text is compared to instead of a document. It is posted as the
+	// body of the request, with the parameters in the URL.
+	Text string
+
+	// Fields are the fields used for similarity (mlt.fl)
+	Fields []string
+
+	// QueryFields boosts the similarity fields, e.g. "title^2" (mlt.qf)
+	QueryFields []string
+
+	// MinTF, MinDF and MaxDF bound the term and document frequency of
+	// the interesting terms
+	MinTF int
+	MinDF int
+	MaxDF int
+
+	// MaxQueryTerms bounds the number of interesting terms
+	MaxQueryTerms int
+
+	// Boost weighs the interesting terms by their relevance
+	Boost bool
+
+	// InterestingTerms returns the terms used: "list", "details" or "none"
+	InterestingTerms string
+
+	// MatchInclude returns the document compared to
+	MatchInclude bool
+
+	Rows      int
+	Start     int
+	Filters   []string
+	FieldList []string
+
+	Params   map[string]interface{}
+	handler  string
+	resultCh chan *SolrResponse
+}
+
+// NewSolrMoreLikeThisQuery creates a SolrMoreLikeThisQuery for the
+// document with the given unique key value.
+func NewSolrMoreLikeThisQuery(uniqueKey, id string, fields []string, s, r int, handler string) *SolrMoreLikeThisQuery {
+	params := make(map[string]interface{})
+	params["wt"] = "json"
+
+	return &SolrMoreLikeThisQuery{
+		Query:    Term(uniqueKey, id).String(),
+		Fields:   fields,
+		Start:    s,
+		Rows:     r,
+		Params:   params,
+		handler:  handler,
+		resultCh: make(chan *SolrResponse, 1),
+	}
+}
+
+// NewSolrMoreLikeThisTextQuery creates a SolrMoreLikeThisQuery for a text.
+func NewSolrMoreLikeThisTextQuery(text string, fields []string, s, r int, handler string) *SolrMoreLikeThisQuery {
+	q := NewSolrMoreLikeThisQuery("", "", fields, s, r, handler)
+	q.Query = ""
+	q.Text = text
+	return q
+}
+
+func (q *SolrMoreLikeThisQuery) Handler() string {
+	return q.handler
+}
+
+func (q *SolrMoreLikeThisQuery) ResultCh() chan *SolrResponse {
+	return q.resultCh
+}
+
+func (q *SolrMoreLikeThisQuery) Wait() *SolrResponse {
+	return <-q.ResultCh()
+}
+
+func (q *SolrMoreLikeThisQuery) GetRows() int {
+	return q.Rows
+}
+
+func (q *SolrMoreLikeThisQuery) GetStart() int {
+	return q.Start
+}
+
+func (q *SolrMoreLikeThisQuery) Bytes() []byte {
+	if len(q.Text) > 0 {
+		return []byte(q.Text)
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"params": q.params()})
+	if err != nil {
+		glog.Error(err)
+	}
+
+	return b
+}
+
+// ContentType is text/plain when comparing to a text, and JSON otherwise.
+func (q *SolrMoreLikeThisQuery) ContentType() string {
+	if len(q.Text) > 0 {
+		return "text/plain"
+	}
+
+	return "application/json"
+}
+
+// URLParams returns the parameters when comparing to a text, since the
+// body holds the text.
+func (q *SolrMoreLikeThisQuery) URLParams() url.Values {
+	if len(q.Text) == 0 {
+		return nil
+	}
+
+	values := make(url.Values)
+	for k, v := range q.params() {
+		switch v := v.(type) {
+		case []string:
+			values[k] = v
+		default:
+			values.Set(k, fmt.Sprint(v))
+		}
+	}
+
+	return values
+}
+
+func (q *SolrMoreLikeThisQuery) params() map[string]interface{} {
+	params := make(map[string]interface{}, len(q.Params)+16)
+
+	if len(q.Text) == 0 {
+		params["q"] = q.Query
+	}
+
+	if len(q.Fields) > 0 {
+		params["mlt.fl"] = strings.Join(q.Fields, ",")
+	}
+
+	if len(q.QueryFields) > 0 {
+		params["mlt.qf"] = strings.Join(q.QueryFields, ",")
+	}
+
+	if q.MinTF > 0 {
+		params["mlt.mintf"] = q.MinTF
+	}
+
+	if q.MinDF > 0 {
+		params["mlt.mindf"] = q.MinDF
+	}
+
+	if q.MaxDF > 0 {
+		params["mlt.maxdf"] = q.MaxDF
+	}
+
+	if q.MaxQueryTerms > 0 {
+		params["mlt.maxqt"] = q.MaxQueryTerms
+	}
+
+	if q.Boost {
+		params["mlt.boost"] = true
+	}
+
+	if len(q.InterestingTerms) > 0 {
+		params["mlt.interestingTerms"] = q.InterestingTerms
+	}
+
+	if q.MatchInclude {
+		params["mlt.match.include"] = true
+	}
+
+	if len(q.Filters) > 0 {
+		params["fq"] = q.Filters
+	}
+
+	if len(q.FieldList) > 0 {
+		params["fl"] = strings.Join(q.FieldList, ",")
+	}
+
+	for k, v := range q.Params {
+		params[k] = v
+	}
+
+	params["start"] = q.Start
+	params["rows"] = q.Rows
+
+	return params
+}
+
+// InterestingTerm is a term MoreLikeThis used to find similar documents.
+// Boost is only known with InterestingTerms set to "details".
+type InterestingTerm struct {
+	Term  string
+	Boost float64
+}
+
+// decodeInterestingTerms decodes the "interestingTerms" section, a list
+// of terms, or a [term, boost, ...] list with details.
+func decodeInterestingTerms(v interface{}) []InterestingTerm {
+	var terms []InterestingTerm
+
+	switch list := v.(type) {
+	case []interface{}:
+		for i := 0; i < len(list); i++ {
+			term, ok := list[i].(string)
+			if !ok {
+				continue
+			}
+
+			it := InterestingTerm{Term: term}
+			if i+1 < len(list) {
+				if boost, ok := list[i+1].(float64); ok {
+					it.Boost = boost
+					i++
+				}
+			}
+
+			terms = append(terms, it)
+		}
+
+	case map[string]interface{}:
+		for term, b := range list {
+			boost, _ := b.(float64)
+			terms = append(terms, InterestingTerm{Term: term, Boost: boost})
+		}
+	}
+
+	return terms
+}
@@ -0,0 +1,132 @@
+package gora
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSolrMoreLikeThisQuery(t *testing.T) {
+	query := NewSolrMoreLikeThisQuery("id", "doc:1", []string{"title", "body"}, 0, 5, "mlt")
+	query.MinTF = 1
+	query.MinDF = 2
+	query.Boost = true
+	query.InterestingTerms = "details"
+	query.MatchInclude = true
+	query.Filters = []string{"type_s:product"}
+	query.FieldList = []string{"id", "score"}
+
+	expected := map[string]interface{}{
+		"q":                    `id:doc\:1`,
+		"mlt.fl":               "title,body",
+		"mlt.mintf":            float64(1),
+		"mlt.mindf":            float64(2),
+		"mlt.boost":            true,
+		"mlt.interestingTerms": "details",
+		"mlt.match.include":    true,
+		"fq":                   []interface{}{"type_s:product"},
+		"fl":                   "id,score",
+		"wt":                   "json",
+		"start":                float64(0),
+		"rows":                 float64(5),
+	}
+
+	var result struct {
+		Params map[string]interface{}
+	}
+	if err := json.Unmarshal(query.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result.Params, expected) {
+		t.Error("Result was unexpected ", result.Params)
+	}
+
+	if query.URLParams() != nil || query.ContentType() != "application/json" {
+		t.Error("Expected the parameters in the JSON body")
+	}
+
+	// The text is posted as is, with the parameters in the URL
+	query = NewSolrMoreLikeThisTextQuery("red leather shoes", []string{"title"}, 0, 5, "mlt")
+	query.Filters = []string{"type_s:product", "in_stock_b:true"}
+	if string(query.Bytes()) != "red leather shoes" || query.ContentType() != "text/plain" {
+		t.Errorf("Unexpected body %s of type %s", query.Bytes(), query.ContentType())
+	}
+
+	params := query.URLParams()
+	if params.Get("mlt.fl") != "title" || params.Get("rows") != "5" || params.Get("wt") != "json" ||
+		len(params["fq"]) != 2 || params.Get("q") != "" || params.Get("stream.body") != "" {
+		t.Error("Result was unexpected ", params)
+	}
+}
+
+func TestMoreLikeThisText(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path != "/solr/core/mlt" || r.URL.Query().Get("mlt.fl") != "title" ||
+			r.Header.Get("Content-Type") != "text/plain" || string(body) != "red leather shoes" {
+			t.Errorf("Unexpected request %s %s %s", r.URL, r.Header.Get("Content-Type"), body)
+		}
+
+		io.WriteString(w, `{"responseHeader": {"status": 0, "QTime": 1}, "response": {"numFound": 0, "start": 0, "docs": []}}`)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewHttpSolrClient(server.URL, "core")
+	query := NewSolrMoreLikeThisTextQuery("red leather shoes", []string{"title"}, 0, 5, "mlt")
+
+	resp, _ := client.Execute(query)
+	if resp.Error != nil {
+		t.Errorf("Unexpected error %v", resp.Error)
+	}
+}
+
+func TestMoreLikeThisResponse(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"match": {"numFound": 1, "start": 0, "docs": [{"id": "doc:1"}]},
+		"response": {"numFound": 2, "start": 0, "docs": [{"id": "doc:2"}, {"id": "doc:3"}]},
+		"interestingTerms": ["title:leather", 1.0, "title:shoes", 0.5]
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if resp.Match.NumFound != 1 || resp.Match.Docs[0]["id"] != "doc:1" || len(resp.Response.Docs) != 2 {
+		t.Errorf("Unexpected documents %+v %+v", resp.Match, resp.Response)
+	}
+
+	expected := []InterestingTerm{{"title:leather", 1}, {"title:shoes", 0.5}}
+	if !reflect.DeepEqual(resp.InterestingTerms, expected) {
+		t.Errorf("Unexpected interesting terms %v", resp.InterestingTerms)
+	}
+
+	raw = []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {"numFound": 1, "start": 0, "docs": [{"id": "doc:1"}]},
+		"moreLikeThis": {"doc:1": {"numFound": 1, "start": 0, "docs": [{"id": "doc:2"}]}},
+		"interestingTerms": ["leather", "shoes"]
+	}`)
+
+	resp, err = SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if similar := resp.MoreLikeThis["doc:1"]; similar == nil || similar.Docs[0]["id"] != "doc:2" {
+		t.Errorf("Unexpected similar documents %+v", resp.MoreLikeThis)
+	}
+
+	expected = []InterestingTerm{{Term: "leather"}, {Term: "shoes"}}
+	if !reflect.DeepEqual(resp.InterestingTerms, expected) {
+		t.Errorf("Unexpected interesting terms %v", resp.InterestingTerms)
+	}
+}
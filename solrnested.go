@@ -0,0 +1,142 @@
+package gora
+
+import (
+	"strconv"
+)
+
+// ChildDocumentsField holds the anonymous child documents of a document,
+// as opposed to the labelled ones held by ordinary fields. Struct
+// documents declare children with a `solr:"_childDocuments_"` tag, or
+// with any tagged field of a struct or slice of structs.
+const ChildDocumentsField = "_childDocuments_"
+
+// AddChildDocuments appends anonymous child documents to a parent
+// document. Labelled children are set like any field, e.g.
+// parent["comments"] = []map[string]interface{}{...}.
+func AddChildDocuments(parent map[string]interface{}, children ...map[string]interface{}) {
+	var existing []interface{}
+	switch docs := parent[ChildDocumentsField].(type) {
+	case []interface{}:
+		existing = docs
+	case []map[string]interface{}:
+		for _, doc := range docs {
+			existing = append(existing, doc)
+		}
+	}
+
+	for _, child := range children {
+		existing = append(existing, child)
+	}
+
+	parent[ChildDocumentsField] = existing
+}
+
+// BlockJoinParent selects the parents of the documents matching
+// childQuery. allParents must match every parent document, and none of
+// the children, e.g. Term("doc_type", "product").
+func BlockJoinParent(allParents QueryNode, childQuery QueryNode) *LocalParamsNode {
+	return LocalParams("parent", map[string]string{"which": allParents.String()}, childQuery)
+}
+
+// BlockJoinChild selects the children of the documents matching
+// parentQuery. allParents must match every parent document, and none of
+// the children.
+func BlockJoinChild(allParents QueryNode, parentQuery QueryNode) *LocalParamsNode {
+	return LocalParams("child", map[string]string{"of": allParents.String()}, parentQuery)
+}
+
+// ChildTransformer renders the [child] transformer for SolrQuery.Fields,
+// returning the children of each document, see DocumentCollection.Nested.
+// parentFilter may be empty if the schema has a _nest_path_ field,
+// childFilter may be empty to return every child, and a zero limit
+// keeps Solr's default of 10 children.
+func ChildTransformer(parentFilter, childFilter string, limit int) string {
+	params := make(map[string]string, 3)
+	if len(parentFilter) > 0 {
+		params["parentFilter"] = parentFilter
+	}
+
+	if len(childFilter) > 0 {
+		params["childFilter"] = childFilter
+	}
+
+	if limit != 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	return Transformer("child", params)
+}
+
+// NestedDocument is a document with its child documents split off its
+// fields, as returned by the [child] transformer.
+type NestedDocument struct {
+	Fields map[string]interface{}
+
+	// Children are the anonymous child documents
+	Children []*NestedDocument
+
+	// Labelled are the child documents held by fields, by field
+	Labelled map[string][]*NestedDocument
+}
+
+// Nested returns the documents of the collection with their child
+// documents decoded. A field holding a document, or a non-empty list of
+// documents only, is taken as labelled children.
+func (c *DocumentCollection) Nested() []*NestedDocument {
+	docs := make([]*NestedDocument, len(c.Docs))
+	for i, doc := range c.Docs {
+		docs[i] = newNestedDocument(doc)
+	}
+
+	return docs
+}
+
+func newNestedDocument(doc map[string]interface{}) *NestedDocument {
+	nested := &NestedDocument{Fields: make(map[string]interface{}, len(doc))}
+
+	for name, v := range doc {
+		children, ok := childDocuments(v)
+		switch {
+		case !ok:
+			nested.Fields[name] = v
+
+		case name == ChildDocumentsField:
+			nested.Children = children
+
+		default:
+			if nested.Labelled == nil {
+				nested.Labelled = make(map[string][]*NestedDocument)
+			}
+			nested.Labelled[name] = children
+		}
+	}
+
+	return nested
+}
+
+// childDocuments decodes a field value holding child documents.
+func childDocuments(v interface{}) ([]*NestedDocument, bool) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return []*NestedDocument{newNestedDocument(value)}, true
+
+	case []interface{}:
+		if len(value) == 0 {
+			return nil, false
+		}
+
+		children := make([]*NestedDocument, len(value))
+		for i, item := range value {
+			doc, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+
+			children[i] = newNestedDocument(doc)
+		}
+
+		return children, true
+	}
+
+	return nil, false
+}
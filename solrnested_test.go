@@ -0,0 +1,132 @@
+package gora
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestChildDocumentsUpdate(t *testing.T) {
+	parent := map[string]interface{}{"id": "p1", "doc_type": "product"}
+	AddChildDocuments(parent, map[string]interface{}{"id": "s1"})
+	AddChildDocuments(parent, map[string]interface{}{"id": "s2"})
+	parent["reviews"] = []map[string]interface{}{{"id": "r1", "stars_i": 5}}
+
+	query := NewSolrUpdateQuery(parent)
+	query.Commit = NoCommit()
+
+	expected := `{"add":{"doc":{"_childDocuments_":[{"id":"s1"},{"id":"s2"}],"doc_type":"product","id":"p1","reviews":[{"id":"r1","stars_i":5}]}}}`
+	if b := string(query.Bytes()); b != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+}
+
+type review struct {
+	ID    string `solr:"id"`
+	Stars int    `solr:"stars_i"`
+}
+
+type sku struct {
+	ID    string `solr:"id"`
+	Color string `solr:"color_s"`
+}
+
+type product struct {
+	ID      string   `solr:"id"`
+	SKUs    []sku    `solr:"_childDocuments_,omitempty"`
+	Reviews []review `solr:"reviews,omitempty"`
+}
+
+func TestChildDocumentsStruct(t *testing.T) {
+	p := product{
+		ID:      "p1",
+		SKUs:    []sku{{"s1", "red"}},
+		Reviews: []review{{"r1", 5}},
+	}
+
+	doc, err := EncodeDocument(p)
+	if err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	b, _ := json.Marshal(doc)
+	expected := `{"_childDocuments_":[{"color_s":"red","id":"s1"}],"id":"p1","reviews":[{"id":"r1","stars_i":5}]}`
+	if string(b) != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+
+	var decoded map[string]interface{}
+	json.Unmarshal(b, &decoded)
+
+	var out product
+	if err := DecodeDocument(decoded, &out); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(out, p) {
+		t.Errorf("Unexpected document %+v", out)
+	}
+}
+
+func TestBlockJoinQueries(t *testing.T) {
+	allParents := Term("doc_type", "product")
+
+	q := BlockJoinParent(allParents, Term("color_s", "red")).String()
+	if q != "{!parent which=doc_type:product}color_s:red" {
+		t.Errorf("Unexpected query %s", q)
+	}
+
+	q = BlockJoinChild(allParents, Term("brand_s", "Acme Co")).String()
+	if q != `{!child of=doc_type:product}brand_s:Acme\ Co` {
+		t.Errorf("Unexpected query %s", q)
+	}
+
+	fl := ChildTransformer("doc_type:product", "color_s:red", 5)
+	if fl != "[child childFilter=color_s:red limit=5 parentFilter=doc_type:product]" {
+		t.Errorf("Unexpected transformer %s", fl)
+	}
+
+	if fl := ChildTransformer("", "", 0); fl != "[child]" {
+		t.Errorf("Unexpected transformer %s", fl)
+	}
+}
+
+func TestNestedDocuments(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {"numFound": 1, "start": 0, "docs": [{
+			"id": "p1",
+			"tags_ss": ["a", "b"],
+			"_childDocuments_": [{"id": "s1", "color_s": "red"}],
+			"reviews": [{"id": "r1", "stars_i": 5}, {"id": "r2", "stars_i": 3}],
+			"manual": {"id": "m1"}
+		}]}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	docs := resp.Response.Nested()
+	if len(docs) != 1 {
+		t.Fatalf("Unexpected documents %v", docs)
+	}
+
+	doc := docs[0]
+	if !reflect.DeepEqual(doc.Fields, map[string]interface{}{"id": "p1", "tags_ss": []interface{}{"a", "b"}}) {
+		t.Errorf("Unexpected fields %v", doc.Fields)
+	}
+
+	if len(doc.Children) != 1 || doc.Children[0].Fields["color_s"] != "red" {
+		t.Errorf("Unexpected children %v", doc.Children)
+	}
+
+	if len(doc.Labelled["reviews"]) != 2 || doc.Labelled["reviews"][1].Fields["id"] != "r2" {
+		t.Errorf("Unexpected reviews %v", doc.Labelled["reviews"])
+	}
+
+	if len(doc.Labelled["manual"]) != 1 || doc.Labelled["manual"][0].Fields["id"] != "m1" {
+		t.Errorf("Unexpected manual %v", doc.Labelled["manual"])
+	}
+}
@@ -1,10 +1,11 @@
 package gora
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"net/url"
+	"reflect"
 	"strings"
 
 	"github.com/wirelessregistry/glog"
@@ -13,13 +14,60 @@ import (
 // SolrQuery represents a SolrJob that can be submitted to a pool.
 // It is a bog standard query representation.
 type SolrQuery struct {
-	Rows     int
-	Start    int
-	Query    string
-	Facet    *string // this has to be a raw json facet query!
-	Filter   *string // this has to be a raw json filter query!
-	Sort     *string // field order
-	Params   map[string]interface{}
+	Rows   int
+	Start  int
+	Query  string
+	Facet  *string // this has to be a raw json facet query!
+	Filter *string // this has to be a raw json filter query!
+	Sort   *string // field order
+	Params map[string]interface{}
+
+	// Filters are sent after Filter, in order
+	Filters []*FilterQuery
+
+	// Fields lists the fields to return (fl), including pseudo-fields
+	// such as "score", aliases ("name:name_s") and transformers, see Transformer
+	Fields []string
+
+	// Highlight enables highlighting, see SolrResponse.Highlighting
+	Highlight *HighlightOptions
+
+	// Group enables result grouping, see SolrResponse.Grouped
+	Group *GroupOptions
+
+	// Collapse adds a {!collapse} filter, and Expand returns the collapsed
+	// documents, see SolrResponse.Expanded
+	Collapse *CollapseOptions
+	Expand   *ExpandOptions
+
+	// Spellcheck enables the spellcheck component, see SolrResponse.Spellcheck
+	Spellcheck *SpellcheckOptions
+
+	// Stats enables the stats component, see SolrResponse.Stats
+	Stats []*StatsField
+
+	// Debug lists the debug information to return: "query", "timing",
+	// "results" or "all", see SolrResponse.Debug
+	Debug []string
+
+	// ExplainStructured returns the score explanations as nested
+	// Explanations instead of text
+	ExplainStructured bool
+
+	// TimeAllowed bounds the search time in milliseconds, see
+	// SolrResponse.PartialResults
+	TimeAllowed int
+
+	// JSONFacets are sent as the JSON Facet API request, in place of Facet
+	JSONFacets map[string]JSONFacet
+
+	// CursorMark enables deep paging, see SolrCursor
+	CursorMark string
+
+	// OnDocument, if set, receives the documents one at a time as the
+	// response is decoded, see SolrStreamingJob
+	OnDocument func(map[string]interface{}) error
+
 	handler  string
 	resultCh chan *SolrResponse
 }
@@ -44,7 +92,6 @@ func NewSolrQuery(q string, s, r int, filter, facet, sort *string, handler strin
 func NewSolrSpatialQuery(q, stype, sfield string, lat, lon, d float64, s, r int, filter, facet, sort *string, handler string) *SolrQuery {
 	params := make(map[string]interface{})
 	params["wt"] = "json"
-	params["fq"] = fmt.Sprintf("{!%s sfield=%s}", stype, sfield)
 	params["pt"] = fmt.Sprintf("%f,%f", lat, lon)
 	params["d"] = fmt.Sprintf("%f", d)
 
@@ -54,6 +101,7 @@ func NewSolrSpatialQuery(q, stype, sfield string, lat, lon, d float64, s, r int,
 		Rows:     r,
 		Facet:    facet,
 		Filter:   filter,
+		Filters:  []*FilterQuery{NewFilterQuery(fmt.Sprintf("{!%s sfield=%s}", stype, sfield))},
 		Sort:     sort,
 		Params:   params,
 		handler:  handler,
@@ -61,8 +109,37 @@ func NewSolrSpatialQuery(q, stype, sfield string, lat, lon, d float64, s, r int,
 	}
 }
 
-func escape(s string) string {
-	return "\"" + s + "\""
+// SetQuery renders a QueryNode as the query.
+func (q *SolrQuery) SetQuery(node QueryNode) *SolrQuery {
+	q.Query = node.String()
+	return q
+}
+
+// AddFilter appends a filter query, returning it so that its options
+// can be set.
+func (q *SolrQuery) AddFilter(fq string) *FilterQuery {
+	f := NewFilterQuery(fq)
+	q.Filters = append(q.Filters, f)
+	return f
+}
+
+// AddStatsField adds a field to the stats component, returning it so
+// that its options can be set.
+func (q *SolrQuery) AddStatsField(field string) *StatsField {
+	s := NewStatsField(field)
+	q.Stats = append(q.Stats, s)
+	return s
+}
+
+// AddFacet adds a facet, or an aggregation over all matching documents,
+// to the JSON Facet API request.
+func (q *SolrQuery) AddFacet(name string, facet JSONFacet) *SolrQuery {
+	if q.JSONFacets == nil {
+		q.JSONFacets = make(map[string]JSONFacet)
+	}
+
+	q.JSONFacets[name] = facet
+	return q
 }
 
 func (q *SolrQuery) Handler() string {
@@ -77,6 +154,78 @@ func (q *SolrQuery) Wait() *SolrResponse {
 	return <-q.ResultCh()
 }
 
+func (q *SolrQuery) DocumentHandler() func(map[string]interface{}) error {
+	return q.OnDocument
+}
+
+// StreamDocuments has the documents of the response sent to ch as they
+// are decoded. The channel is not closed; the caller can do so once the
+// job's response has been received. ctx should be the context the job
+// runs under: once it is done, decoding stops with the context's error
+// instead of waiting on a reader that is gone.
+func (q *SolrQuery) StreamDocuments(ctx context.Context, ch chan<- map[string]interface{}) {
+	q.OnDocument = func(doc map[string]interface{}) error {
+		select {
+		case ch <- doc:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// params returns the Params along with the ones derived from the
+// query's options. Params set by hand take precedence over options.
+func (q *SolrQuery) params() map[string]interface{} {
+	params := make(map[string]interface{}, len(q.Params)+8)
+
+	if q.TimeAllowed > 0 {
+		params["timeAllowed"] = q.TimeAllowed
+	}
+
+	if len(q.Debug) > 0 {
+		params["debug"] = q.Debug
+	}
+
+	if q.ExplainStructured {
+		params["debug.explain.structured"] = true
+	}
+
+	if len(q.Stats) > 0 {
+		params["stats"] = true
+		params["stats.field"] = statsFields(q.Stats)
+	}
+
+	if q.Highlight != nil {
+		q.Highlight.addParams(params)
+	}
+
+	if q.Group != nil {
+		q.Group.addParams(params)
+	}
+
+	if q.Expand != nil {
+		q.Expand.addParams(params)
+	}
+
+	if q.Spellcheck != nil {
+		q.Spellcheck.addParams(params)
+	}
+
+	for k, v := range q.Params {
+		params[k] = v
+	}
+
+	params["start"] = q.Start
+	params["rows"] = q.Rows
+
+	if len(q.CursorMark) > 0 {
+		params["cursorMark"] = q.CursorMark
+	}
+
+	return params
+}
+
 func (q *SolrQuery) GetRows() int {
 	return q.Rows
 }
@@ -97,18 +246,36 @@ func (q *SolrQuery) Bytes() []byte {
 		query["sort"] = *q.Sort
 	}
 
-	if q.Filter != nil {
+	if len(q.Filters) > 0 || q.Collapse != nil {
+		filters := make([]string, 0, len(q.Filters)+2)
+		if q.Filter != nil {
+			filters = append(filters, *q.Filter)
+		}
+
+		for _, f := range q.Filters {
+			filters = append(filters, f.String())
+		}
+
+		if q.Collapse != nil {
+			filters = append(filters, q.Collapse.String())
+		}
+
+		query["filter"] = filters
+	} else if q.Filter != nil {
 		query["filter"] = *q.Filter
 	}
 
-	if q.Facet != nil {
+	if len(q.JSONFacets) > 0 {
+		query["facet"] = q.JSONFacets
+	} else if q.Facet != nil {
 		query["facet"] = *q.Facet
 	}
 
-	q.Params["start"] = q.Start
-	q.Params["rows"] = q.Rows
+	if len(q.Fields) > 0 {
+		query["fields"] = q.Fields
+	}
 
-	query["params"] = q.Params
+	query["params"] = q.params()
 
 	b, err := json.Marshal(query)
 	if err != nil {
@@ -118,11 +285,56 @@ func (q *SolrQuery) Bytes() []byte {
 	return b
 }
 
+// Expected document versions with a special meaning, see SolrUpdateQuery.Version.
+const (
+	// VersionMustExist only accepts the update if the document exists
+	VersionMustExist int64 = 1
+
+	// VersionMustNotExist only accepts the update if the document doesn't exist
+	VersionMustNotExist int64 = -1
+)
+
+// SetVersion sets the expected _version_ of a document. Solr rejects the
+// update with a VersionConflictError if the indexed version differs.
+// Besides a version read from Solr, it may be VersionMustExist or
+// VersionMustNotExist; zero removes the check.
+func SetVersion(document map[string]interface{}, version int64) {
+	if version == 0 {
+		delete(document, "_version_")
+		return
+	}
+
+	document["_version_"] = version
+}
+
+// versionedDocument returns the document with its expected version set,
+// leaving the caller's document untouched.
+func versionedDocument(document map[string]interface{}, version int64) map[string]interface{} {
+	if version == 0 {
+		return document
+	}
+
+	doc := make(map[string]interface{}, len(document)+1)
+	for k, v := range document {
+		doc[k] = v
+	}
+
+	SetVersion(doc, version)
+	return doc
+}
+
 // SolrUpdateQuery represents a query that will update or create a new Solr document
 type SolrUpdateQuery struct {
 	Documents map[string]interface{}
-	handler   string
-	resultCh  chan *SolrResponse
+
+	// Version is the expected _version_ of the document, see SetVersion
+	Version int64
+
+	// Commit sets when the update becomes visible; nil sends a hard commit
+	Commit *CommitOptions
+
+	handler  string
+	resultCh chan *SolrResponse
 }
 
 func NewSolrUpdateQuery(document map[string]interface{}) *SolrUpdateQuery {
@@ -133,6 +345,17 @@ func NewSolrUpdateQuery(document map[string]interface{}) *SolrUpdateQuery {
 	}
 }
 
+// NewSolrUpdateQueryFromStruct creates a SolrUpdateQuery from a struct
+// with `solr` field tags. See EncodeDocument.
+func NewSolrUpdateQueryFromStruct(document interface{}) (*SolrUpdateQuery, error) {
+	doc, err := EncodeDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSolrUpdateQuery(doc), nil
+}
+
 func (q *SolrUpdateQuery) Handler() string {
 	return q.handler
 }
@@ -154,18 +377,34 @@ func (q *SolrUpdateQuery) GetStart() int {
 }
 
 func (q *SolrUpdateQuery) Bytes() []byte {
-	b, _ := json.Marshal(q.Documents)
-	buffer := bytes.NewBufferString(fmt.Sprintf("{\"add\":{\"doc\":%s}, \"commit\": {}}", b))
+	b, _ := json.Marshal(versionedDocument(q.Documents, q.Version))
 
-	return buffer.Bytes()
+	return updateBody(addCommand(b, q.Commit.orDefault().CommitWithin), q.Commit)
 }
 
 // SolrBatchUpdateQuery represents a query that will update or create several Solr documents
 type SolrBatchUpdateQuery struct {
 	Documents    []map[string]interface{}
 	CommitWithin int
-	handler      string
-	resultCh     chan *SolrResponse
+
+	// Versions are the expected _version_ of the documents, in order, see
+	// SetVersion. Documents without a version, or with zero, aren't checked.
+	Versions []int64
+
+	// Commit sets when the update becomes visible. If nil, CommitWithin
+	// is used if set, otherwise a hard commit is sent.
+	Commit *CommitOptions
+
+	// MaxErrors, if set, lets the documents that can be indexed be, and
+	// reports the others in SolrResponse.UpdateErrors, as long as there
+	// are no more than MaxErrors of them. A negative value tolerates any
+	// number of failures. The update chain, UpdateChain or the handler's
+	// default, must include the TolerantUpdateProcessorFactory.
+	MaxErrors   int
+	UpdateChain string
+
+	handler  string
+	resultCh chan *SolrResponse
 }
 
 func NewSolrBatchUpdateQuery(documents []map[string]interface{}) *SolrBatchUpdateQuery {
@@ -183,10 +422,35 @@ func NewSolrBatchUpdateQueryCommitWithin(timeInMillis int, documents []map[strin
 	return q
 }
 
+// NewSolrBatchUpdateQueryFromStructs creates a SolrBatchUpdateQuery from a
+// slice of structs with `solr` field tags. See EncodeDocument.
+func NewSolrBatchUpdateQueryFromStructs(documents interface{}) (*SolrBatchUpdateQuery, error) {
+	rv := reflect.ValueOf(documents)
+	if rv.Kind() != reflect.Slice {
+		return nil, ErrBadDocument
+	}
+
+	docs := make([]map[string]interface{}, rv.Len())
+	for i := range docs {
+		doc, err := EncodeDocument(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		docs[i] = doc
+	}
+
+	return NewSolrBatchUpdateQuery(docs), nil
+}
+
 func (q *SolrBatchUpdateQuery) Handler() string {
 	return q.handler
 }
 
+func (q *SolrBatchUpdateQuery) URLParams() url.Values {
+	return tolerantParams(q.MaxErrors, q.UpdateChain)
+}
+
 func (q *SolrBatchUpdateQuery) ResultCh() chan *SolrResponse {
 	return q.resultCh
 }
@@ -204,29 +468,43 @@ func (q *SolrBatchUpdateQuery) GetStart() int {
 }
 
 func (q *SolrBatchUpdateQuery) Bytes() []byte {
+	commit := q.commitOptions()
+
 	docs := make([]string, len(q.Documents))
 	for i, d := range q.Documents {
-		b, _ := json.Marshal(d)
-		if q.CommitWithin > 0 {
-			docs[i] = fmt.Sprintf("\"add\":{\"doc\":%s,\"commitWithin\":%d}", b, q.CommitWithin)
-		} else {
-			docs[i] = fmt.Sprintf("\"add\":{\"doc\":%s}", b)
+		if i < len(q.Versions) {
+			d = versionedDocument(d, q.Versions[i])
 		}
+
+		b, _ := json.Marshal(d)
+		docs[i] = addCommand(b, commit.orDefault().CommitWithin)
+	}
+
+	return updateBody(strings.Join(docs, ","), commit)
+}
+
+func (q *SolrBatchUpdateQuery) commitOptions() *CommitOptions {
+	if q.Commit == nil && q.CommitWithin > 0 {
+		return CommitWithin(q.CommitWithin)
 	}
 
-	buf := strings.Join(docs, ",")
-	var buffer *bytes.Buffer
-	if q.CommitWithin > 0 {
-		buffer = bytes.NewBufferString(fmt.Sprintf("{%s}", buf))
-	} else {
-		buffer = bytes.NewBufferString(fmt.Sprintf("{%s, \"commit\": {}}", buf))
+	return q.Commit
+}
+
+// addCommand renders the add command of an encoded document.
+func addCommand(doc []byte, commitWithin int) string {
+	if commitWithin > 0 {
+		return fmt.Sprintf("\"add\":{\"doc\":%s,\"commitWithin\":%d}", doc, commitWithin)
 	}
 
-	return buffer.Bytes()
+	return fmt.Sprintf("\"add\":{\"doc\":%s}", doc)
 }
 
 // SolrDeleteQuery represents a query that will remove documents
 type SolrDeleteQuery struct {
+	// Commit sets when the deletion becomes visible; nil sends a hard commit
+	Commit *CommitOptions
+
 	handler  string
 	match    string
 	resultCh chan *SolrResponse
@@ -261,15 +539,18 @@ func (q *SolrDeleteQuery) GetStart() int {
 }
 
 func (q *SolrDeleteQuery) Bytes() []byte {
-	query := fmt.Sprintf("{\"delete\":{\"query\":%s}, \"commit\": {}}", strconv.Quote(q.match))
-	buffer := bytes.NewBufferString(query)
+	query := deleteByQueryCommand(q.match, q.Commit.orDefault().CommitWithin)
 
-	return buffer.Bytes()
+	return updateBody(query, q.Commit)
 }
 
 // SolrBatchDeleteQuery represents a query that will remove documents
 type SolrBatchDeleteQuery struct {
-	Ids      []string
+	Ids []string
+
+	// Commit sets when the deletion becomes visible; nil sends a hard commit
+	Commit *CommitOptions
+
 	handler  string
 	resultCh chan *SolrResponse
 }
@@ -303,10 +584,17 @@ func (q *SolrBatchDeleteQuery) GetStart() int {
 }
 
 func (q *SolrBatchDeleteQuery) Bytes() []byte {
-	b, _ := json.Marshal(q.Ids)
-	query := fmt.Sprintf(`"delete":%s`, b)
+	commitWithin := q.Commit.orDefault().CommitWithin
+	if commitWithin <= 0 {
+		b, _ := json.Marshal(q.Ids)
+		return updateBody(fmt.Sprintf(`"delete":%s`, b), q.Commit)
+	}
 
-	buffer := bytes.NewBufferString(fmt.Sprintf(`{%s, "commit": {}}`, query))
+	// the list form of delete can't carry commitWithin
+	deletes := make([]string, len(q.Ids))
+	for i, id := range q.Ids {
+		deletes[i] = deleteCommand(DeleteByID{ID: id}, commitWithin)
+	}
 
-	return buffer.Bytes()
+	return updateBody(strings.Join(deletes, ","), q.Commit)
 }
@@ -36,7 +36,6 @@ func TestSolrQuery(t *testing.T) {
 func TestSpatialQuery(t *testing.T) {
 	expectedParams := make(map[string]interface{})
 	expectedParams["wt"] = "json"
-	expectedParams["fq"] = "{!bbox sfield=latlon}"
 	expectedParams["d"] = "1.000000"
 	expectedParams["pt"] = "1.230000,-4.560000"
 	expectedParams["start"] = float64(0)
@@ -44,6 +43,7 @@ func TestSpatialQuery(t *testing.T) {
 
 	expected := make(map[string]interface{})
 	expected["query"] = "greeting:你好 AND date:1January2016"
+	expected["filter"] = []interface{}{"{!bbox sfield=latlon}"}
 	expected["params"] = expectedParams
 
 	var result map[string]interface{}
@@ -61,6 +61,36 @@ func TestSpatialQuery(t *testing.T) {
 	}
 }
 
+func TestFilterQueries(t *testing.T) {
+	filter := "type_s:product"
+	solrQuery := NewSolrSpatialQuery("*:*", "geofilt", "location", 1.23, -4.56, 5, 0, 10, &filter, nil, nil, "select")
+	solrQuery.Filters[0].Tag("dist")
+	solrQuery.AddFilter("color_s:red").Tag("color", "style")
+	solrQuery.AddFilter("{!frange l=1}div(sales_i,views_i)").NoCache().WithCost(200)
+	solrQuery.AddFilter(Range("price_f", 10, 20).String())
+
+	expected := []interface{}{
+		"type_s:product",
+		"{!geofilt sfield=location tag=dist}",
+		"{!tag=color,style}color_s:red",
+		"{!frange l=1 cache=false cost=200}div(sales_i,views_i)",
+		"price_f:[10 TO 20]",
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(solrQuery.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result["filter"], expected) {
+		t.Error("Result was unexpected ", result["filter"])
+	}
+
+	if f := NewFilterQuery("{!terms f=id v='a}b'}").Tag("ids"); f.String() != "{!terms f=id v='a}b' tag=ids}" {
+		t.Errorf("Unexpected filter %s", f)
+	}
+}
+
 func TestSolrUpdateQuery(t *testing.T) {
 	expected := []byte(`{"add":{"doc":{"deeper":{"one":"one","two":"two"},"id":"test-id","int_list":[1,2,0,3],"nil":null,"string_list":["你好","Jedná se o delší položka",""]}}, "commit": {}}`)
 
@@ -111,6 +141,30 @@ func TestSolrBatchUpdateQuery(t *testing.T) {
 	}
 }
 
+func TestSolrUpdateQueryVersion(t *testing.T) {
+	doc := map[string]interface{}{"id": "1"}
+
+	solrQuery := NewSolrUpdateQuery(doc)
+	solrQuery.Version = VersionMustNotExist
+
+	expected := []byte(`{"add":{"doc":{"_version_":-1,"id":"1"}}, "commit": {}}`)
+	if bytes.Compare(expected, solrQuery.Bytes()) != 0 {
+		t.Errorf("Found unexpected query data: %s", solrQuery.Bytes())
+	}
+
+	if _, ok := doc["_version_"]; ok {
+		t.Error("The document should not be modified")
+	}
+
+	batchQuery := NewSolrBatchUpdateQuery([]map[string]interface{}{doc, {"id": "2"}, {"id": "3"}})
+	batchQuery.Versions = []int64{1634567890123456789, 0}
+
+	expected = []byte(`{"add":{"doc":{"_version_":1634567890123456789,"id":"1"}},"add":{"doc":{"id":"2"}},"add":{"doc":{"id":"3"}}, "commit": {}}`)
+	if bytes.Compare(expected, batchQuery.Bytes()) != 0 {
+		t.Errorf("Found unexpected query data: %s", batchQuery.Bytes())
+	}
+}
+
 func TestSolrDeleteQuery(t *testing.T) {
 	expected := []byte("{\"delete\":{\"query\":\"*:*\"}, \"commit\": {}}")
 	query := NewSolrDeleteQuery("*:*")
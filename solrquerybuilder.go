@@ -0,0 +1,350 @@
+package gora
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryNode is a node of a Lucene query. String renders it, along with its
+// children, with every user supplied value escaped, so that it can be
+// assigned to SolrQuery.Query (see SolrQuery.SetQuery) or used as a filter.
+//
+//	q := And(Term("type_s", input), Or(Phrase("title", input), Fuzzy("title", input, 1)))
+type QueryNode interface {
+	String() string
+}
+
+// EscapeQueryChars escapes the characters that have a meaning in the
+// Lucene query syntax, and whitespace, so that s is read as a single term.
+func EscapeQueryChars(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for _, r := range s {
+		switch r {
+		case '\\', '+', '-', '!', '(', ')', ':', '^', '[', ']', '"', '{', '}',
+			'~', '*', '?', '|', '&', ';', '/', ' ', '\t', '\n', '\r':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+var phraseEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// escape quotes s as a phrase.
+func escape(s string) string {
+	return "\"" + phraseEscaper.Replace(s) + "\""
+}
+
+// queryValue renders a range endpoint. Nil is unbounded, and dates are
+// formatted as Solr expects them.
+func queryValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "*"
+	case string:
+		return EscapeQueryChars(value)
+	case time.Time:
+		return EscapeQueryChars(value.UTC().Format(SolrDateFormat))
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32)
+	}
+
+	return EscapeQueryChars(fmt.Sprint(v))
+}
+
+// fieldPrefix renders "field:", or nothing for the default field.
+func fieldPrefix(field string) string {
+	if len(field) == 0 {
+		return ""
+	}
+
+	return EscapeQueryChars(field) + ":"
+}
+
+// Raw is a query string used as is. It must not contain user input.
+type Raw string
+
+func (r Raw) String() string {
+	return string(r)
+}
+
+// MatchAll matches every document.
+func MatchAll() QueryNode {
+	return Raw("*:*")
+}
+
+// TermNode matches a single term of a field.
+type TermNode struct {
+	Field string
+	Value string
+}
+
+// Term matches a single term. An empty field searches the default field,
+// and an empty value matches the empty string.
+func Term(field, value string) *TermNode {
+	return &TermNode{Field: field, Value: value}
+}
+
+func (n *TermNode) String() string {
+	return fieldPrefix(n.Field) + termValue(n.Value)
+}
+
+// termValue escapes a term, quoting it when empty since "field:" alone is
+// a syntax error.
+func termValue(value string) string {
+	if len(value) == 0 {
+		return `""`
+	}
+
+	return EscapeQueryChars(value)
+}
+
+// PhraseNode matches a sequence of terms, at most Slop positions apart.
+type PhraseNode struct {
+	Field string
+	Text  string
+	Slop  int
+}
+
+func Phrase(field, text string) *PhraseNode {
+	return &PhraseNode{Field: field, Text: text}
+}
+
+// WithSlop sets how far apart the terms of the phrase may be.
+func (n *PhraseNode) WithSlop(slop int) *PhraseNode {
+	n.Slop = slop
+	return n
+}
+
+func (n *PhraseNode) String() string {
+	s := fieldPrefix(n.Field) + escape(n.Text)
+	if n.Slop > 0 {
+		s += "~" + strconv.Itoa(n.Slop)
+	}
+
+	return s
+}
+
+// RangeNode matches the values of a field between From and To. A nil
+// endpoint is unbounded.
+type RangeNode struct {
+	Field       string
+	From        interface{}
+	To          interface{}
+	IncludeFrom bool
+	IncludeTo   bool
+}
+
+// Range matches the values between from and to, both included.
+func Range(field string, from, to interface{}) *RangeNode {
+	return &RangeNode{
+		Field:       field,
+		From:        from,
+		To:          to,
+		IncludeFrom: true,
+		IncludeTo:   true,
+	}
+}
+
+func (n *RangeNode) String() string {
+	open, close := "{", "}"
+	if n.IncludeFrom {
+		open = "["
+	}
+	if n.IncludeTo {
+		close = "]"
+	}
+
+	return fmt.Sprintf("%s%s%s TO %s%s", fieldPrefix(n.Field), open, queryValue(n.From), queryValue(n.To), close)
+}
+
+// WildcardNode matches the terms of a field against a pattern, where
+// "*" matches any sequence of characters and "?" a single one.
+type WildcardNode struct {
+	Field   string
+	Pattern string
+}
+
+func Wildcard(field, pattern string) *WildcardNode {
+	return &WildcardNode{Field: field, Pattern: pattern}
+}
+
+func (n *WildcardNode) String() string {
+	parts := strings.FieldsFunc(n.Pattern, func(r rune) bool { return r == '*' || r == '?' })
+
+	var b strings.Builder
+	b.WriteString(fieldPrefix(n.Field))
+
+	rest := n.Pattern
+	for _, part := range parts {
+		i := strings.Index(rest, part)
+		b.WriteString(rest[:i])
+		b.WriteString(EscapeQueryChars(part))
+		rest = rest[i+len(part):]
+	}
+	b.WriteString(rest)
+
+	return b.String()
+}
+
+// FuzzyNode matches the terms within Distance edits of Value.
+type FuzzyNode struct {
+	Field    string
+	Value    string
+	Distance int
+}
+
+func Fuzzy(field, value string, distance int) *FuzzyNode {
+	return &FuzzyNode{Field: field, Value: value, Distance: distance}
+}
+
+func (n *FuzzyNode) String() string {
+	return fmt.Sprintf("%s%s~%d", fieldPrefix(n.Field), termValue(n.Value), n.Distance)
+}
+
+// BooleanNode combines clauses that must, should, or must not match.
+type BooleanNode struct {
+	MustClauses    []QueryNode
+	ShouldClauses  []QueryNode
+	MustNotClauses []QueryNode
+}
+
+// Bool creates an empty BooleanNode, to be filled with Must, Should and
+// MustNot. A BooleanNode without clauses matches every document.
+func Bool() *BooleanNode {
+	return &BooleanNode{}
+}
+
+// And matches the documents matching every clause.
+func And(clauses ...QueryNode) *BooleanNode {
+	return Bool().Must(clauses...)
+}
+
+// Or matches the documents matching any clause.
+func Or(clauses ...QueryNode) *BooleanNode {
+	return Bool().Should(clauses...)
+}
+
+// Not matches the documents matching none of the clauses.
+func Not(clauses ...QueryNode) *BooleanNode {
+	return Bool().MustNot(clauses...)
+}
+
+func (n *BooleanNode) Must(clauses ...QueryNode) *BooleanNode {
+	n.MustClauses = append(n.MustClauses, clauses...)
+	return n
+}
+
+func (n *BooleanNode) Should(clauses ...QueryNode) *BooleanNode {
+	n.ShouldClauses = append(n.ShouldClauses, clauses...)
+	return n
+}
+
+func (n *BooleanNode) MustNot(clauses ...QueryNode) *BooleanNode {
+	n.MustNotClauses = append(n.MustNotClauses, clauses...)
+	return n
+}
+
+func (n *BooleanNode) String() string {
+	// An empty query is a syntax error, match everything instead
+	if len(n.MustClauses) == 0 && len(n.ShouldClauses) == 0 && len(n.MustNotClauses) == 0 {
+		return "*:*"
+	}
+
+	clauses := make([]string, 0, len(n.MustClauses)+len(n.ShouldClauses)+len(n.MustNotClauses)+1)
+
+	// A purely negative sub query matches nothing unless told what to subtract from
+	if len(n.MustClauses) == 0 && len(n.ShouldClauses) == 0 && len(n.MustNotClauses) > 0 {
+		clauses = append(clauses, "*:*")
+	}
+
+	for _, c := range n.MustClauses {
+		clauses = append(clauses, "+"+c.String())
+	}
+
+	for _, c := range n.ShouldClauses {
+		clauses = append(clauses, c.String())
+	}
+
+	for _, c := range n.MustNotClauses {
+		clauses = append(clauses, "-"+c.String())
+	}
+
+	return "(" + strings.Join(clauses, " ") + ")"
+}
+
+// BoostNode scales the score of a query.
+type BoostNode struct {
+	Query QueryNode
+	Boost float64
+}
+
+func Boost(q QueryNode, boost float64) *BoostNode {
+	return &BoostNode{Query: q, Boost: boost}
+}
+
+func (n *BoostNode) String() string {
+	return n.Query.String() + "^" + strconv.FormatFloat(n.Boost, 'f', -1, 64)
+}
+
+// LocalParamsNode prefixes a query with local params, e.g. {!tag=color}.
+// Type is the query parser, and may be empty.
+type LocalParamsNode struct {
+	Type   string
+	Params map[string]string
+	Query  QueryNode
+}
+
+// LocalParams prefixes a query with a query parser and its parameters.
+func LocalParams(parser string, params map[string]string, q QueryNode) *LocalParamsNode {
+	return &LocalParamsNode{Type: parser, Params: params, Query: q}
+}
+
+// Tag tags a query, so that facets can exclude it.
+func Tag(tag string, q QueryNode) *LocalParamsNode {
+	return LocalParams("", map[string]string{"tag": tag}, q)
+}
+
+var localParamEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// localParamValue quotes a local param value when needed.
+func localParamValue(v string) string {
+	if len(v) > 0 && !strings.ContainsAny(v, " \t\n\r'\"\\{}=$") {
+		return v
+	}
+
+	return "'" + localParamEscaper.Replace(v) + "'"
+}
+
+func (n *LocalParamsNode) String() string {
+	params := make([]string, 0, len(n.Params)+1)
+	if len(n.Type) > 0 {
+		params = append(params, n.Type)
+	}
+
+	keys := make([]string, 0, len(n.Params))
+	for k := range n.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		params = append(params, k+"="+localParamValue(n.Params[k]))
+	}
+
+	query := ""
+	if n.Query != nil {
+		query = n.Query.String()
+	}
+
+	return "{!" + strings.Join(params, " ") + "}" + query
+}
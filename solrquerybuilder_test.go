@@ -0,0 +1,55 @@
+package gora
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEscapeQueryChars(t *testing.T) {
+	expected := `a\:b\ \"c\"\ \(d\)\ \-e\ \&\&\ f\*\?\\`
+	if escaped := EscapeQueryChars(`a:b "c" (d) -e && f*?\`); escaped != expected {
+		t.Errorf("Expected %s, got %s", expected, escaped)
+	}
+}
+
+func TestQueryBuilder(t *testing.T) {
+	input := `shoes" OR id:*`
+	from := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		node     QueryNode
+		expected string
+	}{
+		{Term("type_s", "a:b"), `type_s:a\:b`},
+		{Term("", "hello"), `hello`},
+		{Term("type_s", ""), `type_s:""`},
+		{Not(Term("type_s", "")), `(*:* -type_s:"")`},
+		{Phrase("title", input), `title:"shoes\" OR id:*"`},
+		{Phrase("title", "red shoes").WithSlop(2), `title:"red shoes"~2`},
+		{Range("price_f", 10, nil), `price_f:[10 TO *]`},
+		{&RangeNode{Field: "created_dt", From: from, To: "NOW"}, `created_dt:{2016\-01\-01T00\:00\:00Z TO NOW}`},
+		{Wildcard("name_s", "jo?n* s:"), `name_s:jo?n*\ s\:`},
+		{Fuzzy("name_s", "jon", 1), `name_s:jon~1`},
+		{Fuzzy("name_s", "", 1), `name_s:""~1`},
+		{Boost(Term("title", "shoes"), 2.5), `title:shoes^2.5`},
+		{And(Term("a", "1"), Or(Term("b", "2"), Term("c", "3"))), `(+a:1 +(b:2 c:3))`},
+		{Bool(), `*:*`},
+		{Bool().Must(Term("a", "1")).Should(Term("b", "2")).MustNot(Term("c", "3")), `(+a:1 b:2 -c:3)`},
+		{Not(Term("a", "1")), `(*:* -a:1)`},
+		{Tag("color", Term("color_s", "red")), `{!tag=color}color_s:red`},
+		{LocalParams("edismax", map[string]string{"qf": "title^2 body", "mm": "2"}, Raw(input)), `{!edismax mm=2 qf='title^2 body'}shoes" OR id:*`},
+		{MatchAll(), `*:*`},
+	}
+
+	for _, test := range tests {
+		if s := test.node.String(); s != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, s)
+		}
+	}
+
+	solrQuery := NewSolrQuery("", 0, 10, nil, nil, nil, "select")
+	solrQuery.SetQuery(Term("id", input))
+	if solrQuery.Query != `id:shoes\"\ OR\ id\:\*` {
+		t.Errorf("Unexpected query %s", solrQuery.Query)
+	}
+}
@@ -1,8 +1,10 @@
 package gora
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 )
 
 var (
@@ -14,7 +16,8 @@ var (
 )
 
 // DocumentCollection represents a collection of solr documents
-// and various other metrics
+// and various other metrics. Numbers in the documents are float64,
+// except for _version_, an int64 to pass on to SetVersion.
 type DocumentCollection struct {
 	Docs     []map[string]interface{}
 	NumFound int
@@ -28,11 +31,132 @@ type SolrResponse struct {
 	Status   int
 	QTime    int
 	Error    error
+
+	// NextCursorMark is set when the query used a cursorMark
+	NextCursorMark string
+
+	// JSONFacets is the typed form of Facets
+	JSONFacets *FacetResult
+
+	// Highlighting holds the snippets by document id, then by field
+	Highlighting map[string]map[string][]string
+
+	// PartialResults is set when the search was cut short by timeAllowed
+	PartialResults bool
+
+	// Grouped holds the result grouping, by field or query
+	Grouped map[string]*GroupResult
+
+	// Expanded holds the collapsed documents, by collapsed field value
+	Expanded map[string]*DocumentCollection
+
+	// Suggest holds the suggester results, by dictionary, then by query
+	Suggest map[string]map[string]*SuggestResult
+
+	// Spellcheck holds the spellcheck component results
+	Spellcheck *SpellcheckResult
+
+	// Match is the document a MoreLikeThis query compared to
+	Match *DocumentCollection
+
+	// MoreLikeThis holds the similar documents found by the MoreLikeThis
+	// search component, by document id
+	MoreLikeThis map[string]*DocumentCollection
+
+	// InterestingTerms are the terms a MoreLikeThis query used
+	InterestingTerms []InterestingTerm
+
+	// Stats holds the stats component results, by stats field key
+	Stats map[string]*StatsInfo
+
+	// Debug holds the debug information, see SolrQuery.Debug
+	Debug *DebugInfo
+
+	// UpdateErrors are the failed commands of a tolerant update, see
+	// SolrBatchUpdateQuery.MaxErrors. They are also set when the update
+	// failed for exceeding maxErrors.
+	UpdateErrors []UpdateError
+}
+
+// Suggestions returns the suggestions of a dictionary for a query.
+func (r *SolrResponse) Suggestions(dictionary, q string) []Suggestion {
+	if result, ok := r.Suggest[dictionary][q]; ok {
+		return result.Suggestions
+	}
+
+	return nil
+}
+
+// Facet returns the JSON Facet API result with the given name, or nil.
+func (r *SolrResponse) Facet(name string) *FacetResult {
+	return r.JSONFacets.Facet(name)
+}
+
+// newDocumentCollection decodes a list of documents, such as the
+// "response" section of a Solr response. It must contain "docs", even
+// if empty.
+func newDocumentCollection(response interface{}) (*DocumentCollection, error) {
+	responseMap, ok := response.(map[string]interface{})
+	if !ok {
+		return nil, ErrBadResponseType
+	}
+
+	docs, ok := responseMap["docs"]
+	if !ok {
+		return nil, ErrNoDocs
+	}
+
+	docsSlice, ok := docs.([]interface{})
+	if !ok {
+		return nil, ErrBadDocs
+	}
+
+	// the total amount of results, irrespective of the amount returned in the response
+	num_found, _ := responseMap["numFound"].(float64)
+	start, _ := responseMap["start"].(float64)
+
+	// and the amount actually returned
+	num_results := len(docsSlice)
+
+	coll := DocumentCollection{}
+	coll.NumFound = int(num_found)
+	coll.Start = int(start)
+
+	ds := make([]map[string]interface{}, 0, num_results)
+
+	for i := 0; i < num_results; i++ {
+		document, ok := docsSlice[i].(map[string]interface{})
+		if ok {
+			ds = append(ds, document)
+		}
+	}
+
+	coll.Docs = ds
+	return &coll, nil
+}
+
+// decodeDocumentCollections decodes a map of document lists, such as
+// the "expanded" or "moreLikeThis" sections of a response.
+func decodeDocumentCollections(section map[string]interface{}) (map[string]*DocumentCollection, error) {
+	collections := make(map[string]*DocumentCollection, len(section))
+
+	for key, v := range section {
+		coll, err := newDocumentCollection(v)
+		if err != nil {
+			return nil, err
+		}
+
+		collections[key] = coll
+	}
+
+	return collections, nil
 }
 
 // PopulateResponse will enumerate the fields of the passed map and create
-// a SolrResponse. Only the "responseHeader" field is required. If there
-// is a "response" field, it must contain "docs", even if empty.
+// a SolrResponse. Only the "responseHeader" field and its "status" are
+// required, except in the responses of the real-time get handler, which
+// have a "doc" or a "response" field instead. If there is a "response"
+// field, it must contain "docs", even if empty.
 func PopulateResponse(j map[string]interface{}) (*SolrResponse, error) {
 	// look for a response element, bail if not present
 	response_root := j
@@ -44,7 +168,11 @@ func PopulateResponse(j map[string]interface{}) (*SolrResponse, error) {
 	// do status & qtime, if possible
 	r_header, ok := response_root["responseHeader"].(map[string]interface{})
 	if !ok {
-		return nil, ErrNoResponseHeader
+		if !isRealtimeGetResponse(response_root) {
+			return nil, ErrNoResponseHeader
+		}
+
+		r_header = map[string]interface{}{"status": float64(0)}
 	}
 
 	if status, ok := r_header["status"]; ok {
@@ -53,78 +181,136 @@ func PopulateResponse(j map[string]interface{}) (*SolrResponse, error) {
 		return nil, ErrInvalidHeader
 	}
 
+	// the /export handler doesn't report a QTime
 	if qtime, ok := r_header["QTime"]; ok {
 		r.QTime = int(qtime.(float64))
-	} else {
-		return nil, ErrInvalidHeader
 	}
 
+	if partial, ok := r_header["partialResults"].(bool); ok {
+		r.PartialResults = partial
+	}
+
+	r.UpdateErrors = decodeUpdateErrors(r_header["errors"])
+
 	// now do docs, if they exist in the response
 	if response != nil {
-		responseMap, ok := response.(map[string]interface{})
-		if !ok {
-			return nil, ErrBadResponseType
+		coll, err := newDocumentCollection(response)
+		if err != nil {
+			return nil, err
 		}
 
-		docs, ok := responseMap["docs"]
-		if !ok {
-			return nil, ErrNoDocs
-		}
+		r.Response = coll
+	}
 
-		docsSlice, ok := docs.([]interface{})
-		if !ok {
-			return nil, ErrBadDocs
+	// the real-time get handler returns a single id as "doc", or null
+	if doc, ok := response_root["doc"]; ok && response == nil {
+		r.Response = &DocumentCollection{Docs: []map[string]interface{}{}}
+		if d, ok := doc.(map[string]interface{}); ok {
+			r.Response.Docs = append(r.Response.Docs, d)
+			r.Response.NumFound = 1
 		}
+	}
 
-		// the total amount of results, irrespective of the amount returned in the response
-		num_found := int(responseMap["numFound"].(float64))
+	if match, ok := response_root["match"]; ok {
+		coll, err := newDocumentCollection(match)
+		if err != nil {
+			return nil, err
+		}
+		r.Match = coll
+	}
 
-		// and the amount actually returned
-		num_results := len(docsSlice)
+	if mlt, ok := response_root["moreLikeThis"].(map[string]interface{}); ok {
+		m, err := decodeDocumentCollections(mlt)
+		if err != nil {
+			return nil, err
+		}
+		r.MoreLikeThis = m
+	}
 
-		coll := DocumentCollection{}
-		coll.NumFound = num_found
+	if terms, ok := response_root["interestingTerms"]; ok {
+		r.InterestingTerms = decodeInterestingTerms(terms)
+	}
 
-		ds := make([]map[string]interface{}, 0, num_results)
+	if grouped, ok := response_root["grouped"].(map[string]interface{}); ok {
+		g, err := decodeGrouped(grouped)
+		if err != nil {
+			return nil, err
+		}
+		r.Grouped = g
+	}
 
-		for i := 0; i < num_results; i++ {
-			document, ok := docsSlice[i].(map[string]interface{})
-			if ok {
-				ds = append(ds, document)
-			}
+	if expanded, ok := response_root["expanded"].(map[string]interface{}); ok {
+		e, err := decodeDocumentCollections(expanded)
+		if err != nil {
+			return nil, err
 		}
+		r.Expanded = e
+	}
+
+	if suggest, ok := response_root["suggest"].(map[string]interface{}); ok {
+		r.Suggest = decodeSuggest(suggest)
+	}
+
+	if spellcheck, ok := response_root["spellcheck"].(map[string]interface{}); ok {
+		r.Spellcheck = decodeSpellcheck(spellcheck)
+	}
+
+	if stats, ok := response_root["stats"].(map[string]interface{}); ok {
+		r.Stats = decodeStats(stats)
+	}
+
+	if debug, ok := response_root["debug"].(map[string]interface{}); ok {
+		r.Debug = decodeDebug(debug)
+	}
 
-		coll.Docs = ds
-		r.Response = &coll
+	if highlighting, ok := response_root["highlighting"].(map[string]interface{}); ok {
+		r.Highlighting = decodeHighlighting(highlighting)
+	}
+
+	if cursorMark, ok := response_root["nextCursorMark"].(string); ok {
+		r.NextCursorMark = cursorMark
 	}
 
 	// If facets exist, add them as well
 	facets := response_root["facets"]
 	if facets != nil {
 		r.Facets = facets.(map[string]interface{})
+		r.JSONFacets = newFacetResult(r.Facets)
 	}
 
 	if r.Status >= 400 {
-		solrError, ok := response_root["error"]
-		if ok {
-			errMap, ok := solrError.(map[string]interface{})
-			if ok {
-				solrMsg, ok := errMap["msg"]
-				if ok {
-					r.Error = errors.New(solrMsg.(string))
-				}
-			}
+		errMap, ok := response_root["error"].(map[string]interface{})
+		if !ok {
+			errMap = make(map[string]interface{})
+		}
+
+		solrErr := newSolrError(errMap, r.Status)
+		if len(r.UpdateErrors) == 0 {
+			r.UpdateErrors = toleratedUpdateErrors(solrErr)
 		}
+
+		r.Error = statusError(solrErr)
 	}
 
 	return &r, nil
 }
 
+// isRealtimeGetResponse reports whether a response without a header
+// comes from the real-time get handler.
+func isRealtimeGetResponse(j map[string]interface{}) bool {
+	if _, ok := j["doc"]; ok {
+		return true
+	}
+
+	_, ok := j["response"]
+	return ok
+}
+
 // SolrResponseFromHTTPResponse decodes an HTTP (Solr) response
 func SolrResponseFromHTTPResponse(b []byte) (*SolrResponse, error) {
 	var container map[string]interface{}
 
-	err := json.Unmarshal(b, &container)
+	err := decodeJSON(newDecoder(bytes.NewReader(b)), &container)
 	if err != nil {
 		return nil, err
 	}
@@ -136,3 +322,193 @@ func SolrResponseFromHTTPResponse(b []byte) (*SolrResponse, error) {
 
 	return resp, nil
 }
+
+// SolrResponseFromReader decodes a Solr response as it is read, handing
+// each document of the "response" section to onDoc instead of keeping it.
+// The returned SolrResponse has everything else populated, including
+// NumFound, but no Docs. Decoding stops at the first error returned by onDoc.
+// With a nil onDoc, the documents are kept as SolrResponseFromHTTPResponse does.
+func SolrResponseFromReader(r io.Reader, onDoc func(map[string]interface{}) error) (*SolrResponse, error) {
+	dec := newDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	container := make(map[string]interface{})
+	for dec.More() {
+		key, err := objectKey(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "response" && onDoc != nil {
+			response, err := streamDocumentCollection(dec, onDoc)
+			if err != nil {
+				return nil, err
+			}
+
+			container[key] = response
+			continue
+		}
+
+		var value interface{}
+		if err := decodeJSON(dec, &value); err != nil {
+			return nil, err
+		}
+
+		container[key] = value
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	return PopulateResponse(container)
+}
+
+// streamDocumentCollection decodes the "response" section of a Solr
+// response, handing its documents to onDoc. The section is returned
+// with an empty "docs" list.
+func streamDocumentCollection(dec *json.Decoder, onDoc func(map[string]interface{}) error) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if t == nil {
+		return nil, nil
+	}
+
+	if t != json.Delim('{') {
+		return nil, ErrBadResponseType
+	}
+
+	response := make(map[string]interface{})
+	for dec.More() {
+		key, err := objectKey(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		if key != "docs" {
+			var value interface{}
+			if err := decodeJSON(dec, &value); err != nil {
+				return nil, err
+			}
+
+			response[key] = value
+			continue
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return nil, ErrBadDocs
+		}
+
+		for dec.More() {
+			var doc map[string]interface{}
+			if err := decodeJSON(dec, &doc); err != nil {
+				return nil, err
+			}
+
+			if err := onDoc(doc); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+
+		response[key] = []interface{}{}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func objectKey(dec *json.Decoder) (string, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := t.(string)
+	if !ok {
+		return "", ErrBadResponseType
+	}
+
+	return key, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if t != delim {
+		return ErrBadResponseType
+	}
+
+	return nil
+}
+
+// versionField is the field holding a document's version.
+const versionField = "_version_"
+
+// newDecoder creates a decoder for decodeJSON.
+func newDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec
+}
+
+// decodeJSON decodes the next value of a decoder created by newDecoder
+// into an interface{} or a map. Numbers are decoded as float64, as by
+// json.Unmarshal, except for _version_: a float64 would lose its last
+// digits, so it is decoded as an int64.
+func decodeJSON(dec *json.Decoder, v interface{}) error {
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	switch p := v.(type) {
+	case *interface{}:
+		*p = restoreNumbers(*p)
+	case *map[string]interface{}:
+		restoreNumbers(*p)
+	}
+
+	return nil
+}
+
+// restoreNumbers replaces the json.Number values of a decoded value.
+func restoreNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		f, _ := value.Float64()
+		return f
+
+	case map[string]interface{}:
+		for k, item := range value {
+			if n, ok := item.(json.Number); ok && k == versionField {
+				if i, err := n.Int64(); err == nil {
+					value[k] = i
+					continue
+				}
+			}
+
+			value[k] = restoreNumbers(item)
+		}
+
+	case []interface{}:
+		for i, item := range value {
+			value[i] = restoreNumbers(item)
+		}
+	}
+
+	return v
+}
@@ -1,6 +1,8 @@
 package gora
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -119,3 +121,84 @@ func TestFromHttpResponse(t *testing.T) {
 	}
 
 }
+
+func TestFromReader(t *testing.T) {
+	raw := `{
+		"responseHeader": {"status": 0, "QTime": 3},
+		"response": {
+			"numFound": 3,
+			"start": 0,
+			"docs": [{"id": "1"}, {"id": "2"}, {"id": "3"}]
+		},
+		"facets": {"count": 3}
+	}`
+
+	var ids []interface{}
+	response, err := SolrResponseFromReader(strings.NewReader(raw), func(doc map[string]interface{}) error {
+		ids = append(ids, doc["id"])
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if len(ids) != 3 || ids[2] != "3" {
+		t.Errorf("Unexpected documents %v", ids)
+	}
+
+	if response.Response.NumFound != 3 || len(response.Response.Docs) != 0 {
+		t.Errorf("Unexpected document collection %+v", response.Response)
+	}
+
+	if response.QTime != 3 || response.Facets["count"] != float64(3) {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	stop := errors.New("stop")
+	_, err = SolrResponseFromReader(strings.NewReader(raw), func(doc map[string]interface{}) error {
+		return stop
+	})
+
+	if err != stop {
+		t.Errorf("Expected %v, got %v", stop, err)
+	}
+
+	response, err = SolrResponseFromReader(strings.NewReader(raw), nil)
+	if err != nil || len(response.Response.Docs) != 3 {
+		t.Errorf("Expected 3 documents without a callback, got %v", err)
+	}
+
+	_, err = SolrResponseFromReader(strings.NewReader(raw[:60]), func(doc map[string]interface{}) error {
+		return nil
+	})
+
+	if err == nil {
+		t.Error("Expected an error for a truncated response")
+	}
+}
+
+func TestResponseNumbers(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {"numFound": 1, "start": 0, "docs": [
+			{"id": "1", "price": 9.5, "sizes": [38, 39], "_version_": 1634567890123456789}
+		]},
+		"facets": {"count": 3}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	// Numbers are float64, as they have always been, but for _version_
+	doc := resp.Response.Docs[0]
+	if doc["price"] != 9.5 || doc["sizes"].([]interface{})[1] != float64(39) || resp.Facets["count"] != float64(3) {
+		t.Errorf("Unexpected numbers %v %v", doc, resp.Facets)
+	}
+
+	if doc["_version_"] != int64(1634567890123456789) {
+		t.Errorf("Unexpected version %v", doc["_version_"])
+	}
+}
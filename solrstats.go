@@ -0,0 +1,190 @@
+package gora
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StatsField is a field of the stats component (stats.field). Tags name
+// it so that pivot facets can refer to it, and Exclude drops tagged
+// filters while computing it. Without Stats, Solr computes its default
+// set of statistics.
+type StatsField struct {
+	Field   string
+	Key     string
+	Tags    []string
+	Exclude []string
+
+	// Stats restricts the statistics computed, e.g. "min", "max", "mean"
+	Stats []string
+
+	// Percentiles to compute, e.g. 50, 99.9
+	Percentiles []float64
+
+	// Cardinality estimates the number of distinct values
+	Cardinality bool
+}
+
+// NewStatsField creates a StatsField for a field or function.
+func NewStatsField(field string) *StatsField {
+	return &StatsField{Field: field}
+}
+
+// Tag adds tags to the stats field.
+func (s *StatsField) Tag(tags ...string) *StatsField {
+	s.Tags = append(s.Tags, tags...)
+	return s
+}
+
+// Excluding computes the stats without the filters with the given tags.
+func (s *StatsField) Excluding(tags ...string) *StatsField {
+	s.Exclude = append(s.Exclude, tags...)
+	return s
+}
+
+// WithKey names the stats field in the response.
+func (s *StatsField) WithKey(key string) *StatsField {
+	s.Key = key
+	return s
+}
+
+// WithStats restricts the statistics computed.
+func (s *StatsField) WithStats(stats ...string) *StatsField {
+	s.Stats = append(s.Stats, stats...)
+	return s
+}
+
+// WithPercentiles adds percentiles to the statistics computed.
+func (s *StatsField) WithPercentiles(percentiles ...float64) *StatsField {
+	s.Percentiles = append(s.Percentiles, percentiles...)
+	return s
+}
+
+// WithCardinality adds the cardinality estimate to the statistics computed.
+func (s *StatsField) WithCardinality() *StatsField {
+	s.Cardinality = true
+	return s
+}
+
+// String renders the stats field with its options as local params.
+func (s *StatsField) String() string {
+	params := make([]string, 0, 4+len(s.Stats))
+	if len(s.Key) > 0 {
+		params = append(params, "key="+localParamValue(s.Key))
+	}
+
+	if len(s.Tags) > 0 {
+		params = append(params, "tag="+localParamValue(strings.Join(s.Tags, ",")))
+	}
+
+	if len(s.Exclude) > 0 {
+		params = append(params, "ex="+localParamValue(strings.Join(s.Exclude, ",")))
+	}
+
+	for _, stat := range s.Stats {
+		params = append(params, stat+"=true")
+	}
+
+	if len(s.Percentiles) > 0 {
+		percentiles := make([]string, len(s.Percentiles))
+		for i, p := range s.Percentiles {
+			percentiles[i] = strconv.FormatFloat(p, 'f', -1, 64)
+		}
+		params = append(params, "percentiles="+localParamValue(strings.Join(percentiles, ",")))
+	}
+
+	if s.Cardinality {
+		params = append(params, "cardinality=true")
+	}
+
+	if len(params) == 0 {
+		return s.Field
+	}
+
+	return "{!" + strings.Join(params, " ") + "}" + s.Field
+}
+
+// StatsInfo holds the statistics of a stats field. Min and Max keep the
+// field's type, strings and dates included; the other statistics are
+// only computed for numeric fields.
+type StatsInfo struct {
+	Min          interface{}
+	Max          interface{}
+	Count        int
+	Missing      int
+	Sum          float64
+	SumOfSquares float64
+	Mean         float64
+	Stddev       float64
+
+	// Percentiles maps each requested percentile to its value
+	Percentiles map[float64]float64
+
+	Cardinality    int
+	CountDistinct  int
+	DistinctValues []interface{}
+}
+
+// Percentile returns the value of a requested percentile.
+func (s *StatsInfo) Percentile(p float64) (float64, bool) {
+	v, ok := s.Percentiles[p]
+	return v, ok
+}
+
+// decodeStats decodes the "stats" section of a response, by field key.
+func decodeStats(section map[string]interface{}) map[string]*StatsInfo {
+	fields, ok := section["stats_fields"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	stats := make(map[string]*StatsInfo, len(fields))
+	for key, v := range fields {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		info := &StatsInfo{
+			Min:           m["min"],
+			Max:           m["max"],
+			Count:         intValue(m["count"]),
+			Missing:       intValue(m["missing"]),
+			Cardinality:   intValue(m["cardinality"]),
+			CountDistinct: intValue(m["countDistinct"]),
+		}
+
+		info.Sum, _ = m["sum"].(float64)
+		info.SumOfSquares, _ = m["sumOfSquares"].(float64)
+		info.Mean, _ = m["mean"].(float64)
+		info.Stddev, _ = m["stddev"].(float64)
+		info.DistinctValues, _ = m["distinctValues"].([]interface{})
+
+		if percentiles := namedList(m["percentiles"]); len(percentiles) > 0 {
+			info.Percentiles = make(map[float64]float64, len(percentiles))
+			for _, pair := range percentiles {
+				name, _ := pair[0].(string)
+				p, err := strconv.ParseFloat(name, 64)
+				if err != nil {
+					continue
+				}
+
+				info.Percentiles[p], _ = pair[1].(float64)
+			}
+		}
+
+		stats[key] = info
+	}
+
+	return stats
+}
+
+// statsFields renders the stats fields of a query, in order.
+func statsFields(fields []*StatsField) []string {
+	rendered := make([]string, len(fields))
+	for i, f := range fields {
+		rendered[i] = f.String()
+	}
+
+	return rendered
+}
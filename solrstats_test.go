@@ -0,0 +1,80 @@
+package gora
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStatsField(t *testing.T) {
+	tests := []struct {
+		field    *StatsField
+		expected string
+	}{
+		{NewStatsField("price"), "price"},
+		{NewStatsField("price").Tag("p").Excluding("brand"), "{!tag=p ex=brand}price"},
+		{NewStatsField("price").WithKey("avg price").WithStats("min", "mean"), "{!key='avg price' min=true mean=true}price"},
+		{NewStatsField("price").WithPercentiles(50, 99.9).WithCardinality(), "{!percentiles=50,99.9 cardinality=true}price"},
+	}
+
+	for _, test := range tests {
+		if s := test.field.String(); s != test.expected {
+			t.Errorf("Expected %s. Got %s.", test.expected, s)
+		}
+	}
+}
+
+func TestStatsQuery(t *testing.T) {
+	solrQuery := NewSolrQuery("*:*", 0, 0, nil, nil, nil, "select")
+	solrQuery.AddStatsField("price").Excluding("brand")
+	solrQuery.AddStatsField("popularity")
+	solrQuery.Debug = []string{"all"}
+	solrQuery.ExplainStructured = true
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(solrQuery.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	params := result["params"].(map[string]interface{})
+	if params["stats"] != true || params["debug.explain.structured"] != true {
+		t.Errorf("Unexpected params %v", params)
+	}
+
+	expected := []interface{}{"{!ex=brand}price", "popularity"}
+	if !reflect.DeepEqual(params["stats.field"], expected) {
+		t.Errorf("Unexpected stats fields %v", params["stats.field"])
+	}
+}
+
+func TestStatsResponse(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"response": {"numFound": 3, "start": 0, "docs": []},
+		"stats": {"stats_fields": {
+			"price": {"min": 1.5, "max": 10.0, "count": 3, "missing": 1, "sum": 16.5,
+				"sumOfSquares": 127.25, "mean": 5.5, "stddev": 4.27,
+				"percentiles": ["50.0", 5.0, "99.9", 10.0], "cardinality": 3},
+			"name": {"min": "apple", "max": "pear", "count": 3, "missing": 0}
+		}}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	price := resp.Stats["price"]
+	if price == nil || price.Min != 1.5 || price.Count != 3 || price.Missing != 1 ||
+		price.Mean != 5.5 || price.Cardinality != 3 {
+		t.Fatalf("Unexpected stats %+v", price)
+	}
+
+	if p, ok := price.Percentile(99.9); !ok || p != 10 {
+		t.Errorf("Unexpected percentile %v", price.Percentiles)
+	}
+
+	if name := resp.Stats["name"]; name == nil || name.Min != "apple" || name.Max != "pear" {
+		t.Errorf("Unexpected stats %+v", name)
+	}
+}
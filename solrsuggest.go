@@ -0,0 +1,332 @@
+package gora
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/wirelessregistry/glog"
+)
+
+// SolrSuggestQuery represents a SolrJob for the suggester component,
+// usually behind the /suggest handler. See SolrResponse.Suggest.
+type SolrSuggestQuery struct {
+	Query        string
+	Dictionaries []string
+	Count        int
+
+	// ContextFilter restricts suggestions by their context field (suggest.cfq)
+	ContextFilter string
+
+	Params   map[string]interface{}
+	handler  string
+	resultCh chan *SolrResponse
+}
+
+// NewSolrSuggestQuery creates a SolrSuggestQuery returning up to count
+// suggestions for q from each dictionary.
+func NewSolrSuggestQuery(q string, count int, dictionaries []string, handler string) *SolrSuggestQuery {
+	params := make(map[string]interface{})
+	params["wt"] = "json"
+
+	return &SolrSuggestQuery{
+		Query:        q,
+		Dictionaries: dictionaries,
+		Count:        count,
+		Params:       params,
+		handler:      handler,
+		resultCh:     make(chan *SolrResponse, 1),
+	}
+}
+
+func (q *SolrSuggestQuery) Handler() string {
+	return q.handler
+}
+
+func (q *SolrSuggestQuery) ResultCh() chan *SolrResponse {
+	return q.resultCh
+}
+
+func (q *SolrSuggestQuery) Wait() *SolrResponse {
+	return <-q.ResultCh()
+}
+
+func (q *SolrSuggestQuery) GetRows() int {
+	return q.Count
+}
+
+func (q *SolrSuggestQuery) GetStart() int {
+	return 0
+}
+
+func (q *SolrSuggestQuery) Bytes() []byte {
+	params := make(map[string]interface{}, len(q.Params)+5)
+	for k, v := range q.Params {
+		params[k] = v
+	}
+
+	params["suggest"] = true
+	params["suggest.q"] = q.Query
+
+	if len(q.Dictionaries) > 0 {
+		params["suggest.dictionary"] = q.Dictionaries
+	}
+
+	if q.Count > 0 {
+		params["suggest.count"] = q.Count
+	}
+
+	if len(q.ContextFilter) > 0 {
+		params["suggest.cfq"] = q.ContextFilter
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"params": params})
+	if err != nil {
+		glog.Error(err)
+	}
+
+	return b
+}
+
+// SpellcheckOptions enables the spellcheck component on a SolrQuery.
+// Zero values leave Solr's defaults in place. See SolrResponse.Spellcheck.
+type SpellcheckOptions struct {
+	// Query is checked instead of the main query (spellcheck.q)
+	Query string
+
+	Dictionary string
+
+	// Count is the number of alternatives per misspelled word
+	Count int
+
+	// OnlyMorePopular only suggests words more frequent than the original
+	OnlyMorePopular bool
+
+	// ExtendedResults adds the frequency of each alternative
+	ExtendedResults bool
+
+	// Collate builds corrected queries, tried against the index up to
+	// MaxCollationTries times. CollateExtendedResults details each one.
+	Collate                bool
+	MaxCollations          int
+	MaxCollationTries      int
+	CollateExtendedResults bool
+}
+
+func (s *SpellcheckOptions) addParams(params map[string]interface{}) {
+	params["spellcheck"] = true
+
+	if len(s.Query) > 0 {
+		params["spellcheck.q"] = s.Query
+	}
+
+	if len(s.Dictionary) > 0 {
+		params["spellcheck.dictionary"] = s.Dictionary
+	}
+
+	if s.Count > 0 {
+		params["spellcheck.count"] = s.Count
+	}
+
+	if s.OnlyMorePopular {
+		params["spellcheck.onlyMorePopular"] = true
+	}
+
+	if s.ExtendedResults {
+		params["spellcheck.extendedResults"] = true
+	}
+
+	if s.Collate {
+		params["spellcheck.collate"] = true
+	}
+
+	if s.MaxCollations > 0 {
+		params["spellcheck.maxCollations"] = s.MaxCollations
+	}
+
+	if s.MaxCollationTries > 0 {
+		params["spellcheck.maxCollationTries"] = s.MaxCollationTries
+	}
+
+	if s.CollateExtendedResults {
+		params["spellcheck.collateExtendedResults"] = true
+	}
+}
+
+// SuggestResult holds the suggestions of a dictionary for a query.
+type SuggestResult struct {
+	NumFound    int
+	Suggestions []Suggestion
+}
+
+type Suggestion struct {
+	Term    string
+	Weight  int64
+	Payload string
+}
+
+// SpellcheckResult is the decoded "spellcheck" section of a response.
+type SpellcheckResult struct {
+	CorrectlySpelled bool
+	Suggestions      []*SpellSuggestion
+	Collations       []*Collation
+}
+
+// SpellSuggestion holds the alternatives to a misspelled word.
+type SpellSuggestion struct {
+	Word        string
+	NumFound    int
+	StartOffset int
+	EndOffset   int
+	OrigFreq    int
+
+	Alternatives []SpellAlternative
+}
+
+// SpellAlternative is a correction; Freq is only known with
+// SpellcheckOptions.ExtendedResults.
+type SpellAlternative struct {
+	Word string
+	Freq int
+}
+
+// Collation is a corrected query. Hits and Corrections are only known
+// with SpellcheckOptions.CollateExtendedResults.
+type Collation struct {
+	Query       string
+	Hits        int
+	Corrections map[string]string
+}
+
+// namedList decodes a Solr NamedList, which is a flat [name, value, ...]
+// list by default, or an object with json.nl=map.
+func namedList(v interface{}) [][2]interface{} {
+	switch list := v.(type) {
+	case []interface{}:
+		pairs := make([][2]interface{}, 0, len(list)/2)
+		for i := 0; i+1 < len(list); i += 2 {
+			pairs = append(pairs, [2]interface{}{list[i], list[i+1]})
+		}
+		return pairs
+
+	case map[string]interface{}:
+		pairs := make([][2]interface{}, 0, len(list))
+		for k, v := range list {
+			pairs = append(pairs, [2]interface{}{k, v})
+		}
+		return pairs
+	}
+
+	return nil
+}
+
+func intValue(v interface{}) int {
+	n, _ := v.(float64)
+	return int(n)
+}
+
+// decodeSuggest decodes the "suggest" section of a response, keyed by
+// dictionary, then by query.
+func decodeSuggest(section map[string]interface{}) map[string]map[string]*SuggestResult {
+	suggest := make(map[string]map[string]*SuggestResult, len(section))
+
+	for dictionary, queries := range section {
+		queryMap, ok := queries.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		results := make(map[string]*SuggestResult, len(queryMap))
+		for q, v := range queryMap {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			result := &SuggestResult{NumFound: intValue(m["numFound"])}
+			list, _ := m["suggestions"].([]interface{})
+			for _, item := range list {
+				s, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				weight, _ := s["weight"].(float64)
+				term, _ := s["term"].(string)
+				payload, _ := s["payload"].(string)
+				result.Suggestions = append(result.Suggestions, Suggestion{
+					Term:    term,
+					Weight:  int64(weight),
+					Payload: payload,
+				})
+			}
+
+			results[q] = result
+		}
+
+		suggest[dictionary] = results
+	}
+
+	return suggest
+}
+
+// decodeSpellcheck decodes the "spellcheck" section of a response.
+func decodeSpellcheck(section map[string]interface{}) *SpellcheckResult {
+	result := &SpellcheckResult{}
+
+	if correct, ok := section["correctlySpelled"].(bool); ok {
+		result.CorrectlySpelled = correct
+	}
+
+	for _, pair := range namedList(section["suggestions"]) {
+		word, _ := pair[0].(string)
+		m, ok := pair[1].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		suggestion := &SpellSuggestion{
+			Word:        word,
+			NumFound:    intValue(m["numFound"]),
+			StartOffset: intValue(m["startOffset"]),
+			EndOffset:   intValue(m["endOffset"]),
+			OrigFreq:    intValue(m["origFreq"]),
+		}
+
+		// plain words, or {word, freq} objects with extended results
+		alternatives, _ := m["suggestion"].([]interface{})
+		for _, a := range alternatives {
+			switch alt := a.(type) {
+			case string:
+				suggestion.Alternatives = append(suggestion.Alternatives, SpellAlternative{Word: alt})
+			case map[string]interface{}:
+				w, _ := alt["word"].(string)
+				suggestion.Alternatives = append(suggestion.Alternatives, SpellAlternative{Word: w, Freq: intValue(alt["freq"])})
+			}
+		}
+
+		result.Suggestions = append(result.Suggestions, suggestion)
+	}
+
+	for _, pair := range namedList(section["collations"]) {
+		switch c := pair[1].(type) {
+		case string:
+			result.Collations = append(result.Collations, &Collation{Query: c})
+
+		case map[string]interface{}:
+			collation := &Collation{Hits: intValue(c["hits"])}
+			collation.Query, _ = c["collationQuery"].(string)
+
+			corrections := namedList(c["misspellingsAndCorrections"])
+			if len(corrections) > 0 {
+				collation.Corrections = make(map[string]string, len(corrections))
+				for _, correction := range corrections {
+					collation.Corrections[fmt.Sprint(correction[0])] = fmt.Sprint(correction[1])
+				}
+			}
+
+			result.Collations = append(result.Collations, collation)
+		}
+	}
+
+	return result
+}
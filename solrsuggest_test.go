@@ -0,0 +1,110 @@
+package gora
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSolrSuggestQuery(t *testing.T) {
+	query := NewSolrSuggestQuery("sho", 5, []string{"titles", "brands"}, "suggest")
+	query.ContextFilter = "lang_s:en"
+
+	expected := map[string]interface{}{
+		"params": map[string]interface{}{
+			"wt":                 "json",
+			"suggest":            true,
+			"suggest.q":          "sho",
+			"suggest.count":      float64(5),
+			"suggest.dictionary": []interface{}{"titles", "brands"},
+			"suggest.cfq":        "lang_s:en",
+		},
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(query.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Error("Result was unexpected ", result)
+	}
+}
+
+func TestSpellcheckOptions(t *testing.T) {
+	solrQuery := NewSolrQuery("shoos", 0, 10, nil, nil, nil, "select")
+	solrQuery.Spellcheck = &SpellcheckOptions{Count: 3, Collate: true, MaxCollationTries: 5}
+
+	var result struct {
+		Params map[string]interface{}
+	}
+	if err := json.Unmarshal(solrQuery.Bytes(), &result); err != nil {
+		t.Fatal("Unexpected error ", err)
+	}
+
+	params := result.Params
+	if params["spellcheck"] != true || params["spellcheck.count"] != float64(3) ||
+		params["spellcheck.collate"] != true || params["spellcheck.maxCollationTries"] != float64(5) {
+		t.Error("Result was unexpected ", params)
+	}
+}
+
+func TestSuggestAndSpellcheckResponse(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"status": 0, "QTime": 1},
+		"suggest": {
+			"titles": {
+				"sho": {"numFound": 2, "suggestions": [
+					{"term": "shoes", "weight": 12, "payload": ""},
+					{"term": "shorts", "weight": 3, "payload": "p"}
+				]}
+			}
+		},
+		"spellcheck": {
+			"suggestions": [
+				"shoos", {"numFound": 2, "startOffset": 0, "endOffset": 5, "origFreq": 0,
+					"suggestion": [{"word": "shoes", "freq": 10}, {"word": "shops", "freq": 2}]},
+				"rd", {"numFound": 1, "startOffset": 6, "endOffset": 8, "suggestion": ["red"]}
+			],
+			"correctlySpelled": false,
+			"collations": [
+				"collation", {"collationQuery": "shoes red", "hits": 4,
+					"misspellingsAndCorrections": ["shoos", "shoes", "rd", "red"]},
+				"collation", "shops red"
+			]
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	expected := []Suggestion{{Term: "shoes", Weight: 12}, {Term: "shorts", Weight: 3, Payload: "p"}}
+	if !reflect.DeepEqual(resp.Suggestions("titles", "sho"), expected) {
+		t.Errorf("Unexpected suggestions %v", resp.Suggestions("titles", "sho"))
+	}
+
+	if resp.Suggest["titles"]["sho"].NumFound != 2 || resp.Suggestions("brands", "sho") != nil {
+		t.Errorf("Unexpected suggest section %v", resp.Suggest)
+	}
+
+	spellcheck := resp.Spellcheck
+	if spellcheck.CorrectlySpelled || len(spellcheck.Suggestions) != 2 || len(spellcheck.Collations) != 2 {
+		t.Fatalf("Unexpected spellcheck %+v", spellcheck)
+	}
+
+	shoos := spellcheck.Suggestions[0]
+	if shoos.Word != "shoos" || shoos.EndOffset != 5 || !reflect.DeepEqual(shoos.Alternatives, []SpellAlternative{{"shoes", 10}, {"shops", 2}}) {
+		t.Errorf("Unexpected suggestion %+v", shoos)
+	}
+
+	if rd := spellcheck.Suggestions[1]; !reflect.DeepEqual(rd.Alternatives, []SpellAlternative{{Word: "red"}}) {
+		t.Errorf("Unexpected suggestion %+v", rd)
+	}
+
+	expectedCollation := &Collation{Query: "shoes red", Hits: 4, Corrections: map[string]string{"shoos": "shoes", "rd": "red"}}
+	if !reflect.DeepEqual(spellcheck.Collations[0], expectedCollation) || spellcheck.Collations[1].Query != "shops red" {
+		t.Errorf("Unexpected collations %+v %+v", spellcheck.Collations[0], spellcheck.Collations[1])
+	}
+}
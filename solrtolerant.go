@@ -0,0 +1,101 @@
+package gora
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// toleratedErrorPrefix starts the error.metadata names of the failures
+// reported when an update exceeds maxErrors.
+const toleratedErrorPrefix = "org.apache.solr.common.ToleratedUpdateError--"
+
+// UpdateError is the failure of a single command of an update, reported
+// by the TolerantUpdateProcessor instead of failing the whole update.
+type UpdateError struct {
+	// Type is the command that failed: "ADD", "DELID" or "DELQ"
+	Type string
+
+	// ID is the document id, or the query of a delete by query
+	ID string
+
+	Message string
+}
+
+func (e *UpdateError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Type, e.ID, e.Message)
+}
+
+// tolerantParams returns the tolerant update parameters, sent in the
+// URL of an update. maxErrors is only sent if set; a negative value
+// tolerates any number of failures.
+func tolerantParams(maxErrors int, chain string) url.Values {
+	params := url.Values{}
+	if maxErrors != 0 {
+		if maxErrors < 0 {
+			maxErrors = -1
+		}
+		params.Set("maxErrors", strconv.Itoa(maxErrors))
+	}
+
+	if len(chain) > 0 {
+		params.Set("update.chain", chain)
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	return params
+}
+
+// decodeUpdateErrors decodes the "errors" list of a response header.
+func decodeUpdateErrors(v interface{}) []UpdateError {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	errs := make([]UpdateError, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		e := UpdateError{}
+		e.Type, _ = m["type"].(string)
+		e.ID, _ = m["id"].(string)
+		e.Message, _ = m["message"].(string)
+		errs = append(errs, e)
+	}
+
+	return errs
+}
+
+// toleratedUpdateErrors decodes the failures listed in the metadata of
+// an update that exceeded maxErrors, e.g.
+// "org.apache.solr.common.ToleratedUpdateError--ADD:doc1".
+func toleratedUpdateErrors(e *SolrError) []UpdateError {
+	var errs []UpdateError
+	for name, message := range e.Metadata {
+		if !strings.HasPrefix(name, toleratedErrorPrefix) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(name, toleratedErrorPrefix), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		errs = append(errs, UpdateError{Type: parts[0], ID: parts[1], Message: message})
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].ID < errs[j].ID
+	})
+
+	return errs
+}
@@ -0,0 +1,119 @@
+package gora
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTolerantParams(t *testing.T) {
+	query := NewSolrBatchUpdateQuery([]map[string]interface{}{{"id": "1"}})
+	if query.URLParams() != nil {
+		t.Errorf("Unexpected params %v", query.URLParams())
+	}
+
+	query.MaxErrors = 10
+	if query.Handler() != "update" || query.URLParams().Encode() != "maxErrors=10" {
+		t.Errorf("Unexpected params %s %v", query.Handler(), query.URLParams())
+	}
+
+	query.MaxErrors = -5
+	query.UpdateChain = "tolerant-chain"
+	if query.URLParams().Encode() != "maxErrors=-1&update.chain=tolerant-chain" {
+		t.Errorf("Unexpected params %v", query.URLParams())
+	}
+}
+
+func TestTolerantRequest(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/solr/core/update/json" || r.URL.RawQuery != "commitWithin=1000&maxErrors=10" {
+			t.Errorf("Unexpected URL %s", r.URL)
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"responseHeader": {"status": 400, "QTime": 1}, "error": {"msg": "bad", "code": 400}}`)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewHttpSolrClient(server.URL, "core")
+	query := NewSolrBatchUpdateQuery([]map[string]interface{}{{"id": "1"}})
+	query.handler = "update/json?commitWithin=1000"
+	query.MaxErrors = 10
+
+	// The parameters stay out of the error's origin
+	resp, _ := client.Execute(query)
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) || solrErr.Handler != "update/json?commitWithin=1000" ||
+		solrErr.URL != server.URL+"/solr/core/update/json?commitWithin=1000" {
+		t.Errorf("Unexpected error %v", resp.Error)
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {
+			"errors": [
+				{"type": "ADD", "id": "2", "message": "ERROR: [doc=2] Error adding field 'price_f'='abc'"},
+				{"type": "DELQ", "id": "price_f:[* TO", "message": "Cannot parse 'price_f:[* TO'"}
+			],
+			"maxErrors": 10,
+			"status": 0,
+			"QTime": 3
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	if resp.Error != nil {
+		t.Errorf("Unexpected error %v", resp.Error)
+	}
+
+	expected := []UpdateError{
+		{Type: "ADD", ID: "2", Message: "ERROR: [doc=2] Error adding field 'price_f'='abc'"},
+		{Type: "DELQ", ID: "price_f:[* TO", Message: "Cannot parse 'price_f:[* TO'"},
+	}
+	if !reflect.DeepEqual(resp.UpdateErrors, expected) {
+		t.Errorf("Unexpected update errors %v", resp.UpdateErrors)
+	}
+}
+
+func TestUpdateErrorsExceeded(t *testing.T) {
+	raw := []byte(`{
+		"responseHeader": {"errors": [], "maxErrors": 1, "status": 400, "QTime": 3},
+		"error": {
+			"metadata": [
+				"org.apache.solr.common.ToleratedUpdateError--ADD:3", "ERROR: [doc=3] unknown field 'x'",
+				"org.apache.solr.common.ToleratedUpdateError--ADD:2", "ERROR: [doc=2] unknown field 'x'",
+				"error-class", "org.apache.solr.common.SolrException"
+			],
+			"msg": "ERROR: [doc=2] unknown field 'x'",
+			"code": 400
+		}
+	}`)
+
+	resp, err := SolrResponseFromHTTPResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) {
+		t.Errorf("Expected a SolrError, got %v", resp.Error)
+	}
+
+	expected := []UpdateError{
+		{Type: "ADD", ID: "2", Message: "ERROR: [doc=2] unknown field 'x'"},
+		{Type: "ADD", ID: "3", Message: "ERROR: [doc=3] unknown field 'x'"},
+	}
+	if !reflect.DeepEqual(resp.UpdateErrors, expected) {
+		t.Errorf("Unexpected update errors %v", resp.UpdateErrors)
+	}
+}
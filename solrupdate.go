@@ -0,0 +1,159 @@
+package gora
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DeleteByID deletes a document by id. Route names the shard of the
+// document when the collection uses implicit routing or a router.field,
+// and Version is the document's expected _version_, see SetVersion.
+type DeleteByID struct {
+	ID      string
+	Route   string
+	Version int64
+}
+
+// SolrMixedUpdateQuery represents a SolrJob sending a list of update
+// commands in order: adds, deletes by id or by query, and commits. Solr
+// runs them in the order given, so a document can be deleted and added
+// again, or a commit placed between two sets of changes.
+//
+// Nothing is committed unless a commit is added, or CommitWithin is set.
+type SolrMixedUpdateQuery struct {
+	// CommitWithin, if set, asks Solr to commit the adds and deletes
+	// added afterwards within this many milliseconds
+	CommitWithin int
+
+	// MaxErrors and UpdateChain make the update tolerant, see
+	// SolrBatchUpdateQuery
+	MaxErrors   int
+	UpdateChain string
+
+	commands []string
+	handler  string
+	resultCh chan *SolrResponse
+}
+
+// NewSolrMixedUpdateQuery creates an empty SolrMixedUpdateQuery.
+func NewSolrMixedUpdateQuery() *SolrMixedUpdateQuery {
+	return &SolrMixedUpdateQuery{
+		handler:  "update",
+		resultCh: make(chan *SolrResponse, 1),
+	}
+}
+
+// Add adds documents. A document's expected version is set with
+// SetVersion.
+func (q *SolrMixedUpdateQuery) Add(docs ...map[string]interface{}) *SolrMixedUpdateQuery {
+	for _, doc := range docs {
+		b, _ := json.Marshal(doc)
+		q.commands = append(q.commands, addCommand(b, q.CommitWithin))
+	}
+
+	return q
+}
+
+// AddStruct adds a document from a struct with `solr` field tags. See
+// EncodeDocument.
+func (q *SolrMixedUpdateQuery) AddStruct(document interface{}) error {
+	doc, err := EncodeDocument(document)
+	if err != nil {
+		return err
+	}
+
+	q.Add(doc)
+	return nil
+}
+
+// Delete deletes documents by id.
+func (q *SolrMixedUpdateQuery) Delete(ids ...string) *SolrMixedUpdateQuery {
+	for _, id := range ids {
+		q.commands = append(q.commands, deleteCommand(DeleteByID{ID: id}, q.CommitWithin))
+	}
+
+	return q
+}
+
+// DeleteDocuments deletes documents by id, with their route and
+// expected version.
+func (q *SolrMixedUpdateQuery) DeleteDocuments(deletes ...DeleteByID) *SolrMixedUpdateQuery {
+	for _, d := range deletes {
+		q.commands = append(q.commands, deleteCommand(d, q.CommitWithin))
+	}
+
+	return q
+}
+
+// DeleteByQuery deletes the documents matching a query.
+func (q *SolrMixedUpdateQuery) DeleteByQuery(query string) *SolrMixedUpdateQuery {
+	q.commands = append(q.commands, deleteByQueryCommand(query, q.CommitWithin))
+	return q
+}
+
+// Commit commits the commands added so far. Of the CommitOptions,
+// CommitWithin is ignored; nil sends a hard commit.
+func (q *SolrMixedUpdateQuery) Commit(commit *CommitOptions) *SolrMixedUpdateQuery {
+	if cmd := commit.command(); len(cmd) > 0 {
+		q.commands = append(q.commands, cmd)
+	}
+
+	return q
+}
+
+// Len returns the number of commands.
+func (q *SolrMixedUpdateQuery) Len() int {
+	return len(q.commands)
+}
+
+func (q *SolrMixedUpdateQuery) Handler() string {
+	return q.handler
+}
+
+func (q *SolrMixedUpdateQuery) URLParams() url.Values {
+	return tolerantParams(q.MaxErrors, q.UpdateChain)
+}
+
+func (q *SolrMixedUpdateQuery) ResultCh() chan *SolrResponse {
+	return q.resultCh
+}
+
+func (q *SolrMixedUpdateQuery) Wait() *SolrResponse {
+	return <-q.ResultCh()
+}
+
+func (q *SolrMixedUpdateQuery) GetRows() int {
+	return 0
+}
+
+func (q *SolrMixedUpdateQuery) GetStart() int {
+	return 0
+}
+
+func (q *SolrMixedUpdateQuery) Bytes() []byte {
+	return []byte(fmt.Sprintf("{%s}", strings.Join(q.commands, ",")))
+}
+
+// deleteCommand renders the delete command of a document id.
+func deleteCommand(d DeleteByID, commitWithin int) string {
+	b, _ := json.Marshal(struct {
+		ID           string `json:"id"`
+		Route        string `json:"_route_,omitempty"`
+		Version      int64  `json:"_version_,omitempty"`
+		CommitWithin int    `json:"commitWithin,omitempty"`
+	}{d.ID, d.Route, d.Version, commitWithin})
+
+	return fmt.Sprintf(`"delete":%s`, b)
+}
+
+// deleteByQueryCommand renders the delete command of a query.
+func deleteByQueryCommand(query string, commitWithin int) string {
+	b, _ := json.Marshal(query)
+	if commitWithin > 0 {
+		return fmt.Sprintf(`"delete":{"query":%s,"commitWithin":%d}`, b, commitWithin)
+	}
+
+	return fmt.Sprintf(`"delete":{"query":%s}`, b)
+}
@@ -0,0 +1,65 @@
+package gora
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSolrMixedUpdateQuery(t *testing.T) {
+	doc := map[string]interface{}{"id": "1", "title": "new"}
+	SetVersion(doc, VersionMustExist)
+
+	query := NewSolrMixedUpdateQuery().
+		Delete("1").
+		Add(doc).
+		DeleteDocuments(DeleteByID{ID: "2", Route: "shard2!", Version: 1634567890123456789}).
+		DeleteByQuery(`type_s:"draft"`).
+		Commit(SoftCommit())
+
+	query.CommitWithin = 500
+	query.Delete("3").Commit(nil)
+
+	expected := `{"delete":{"id":"1"},` +
+		`"add":{"doc":{"_version_":1,"id":"1","title":"new"}},` +
+		`"delete":{"id":"2","_route_":"shard2!","_version_":1634567890123456789},` +
+		`"delete":{"query":"type_s:\"draft\""},` +
+		`"commit": {"softCommit":true},` +
+		`"delete":{"id":"3","commitWithin":500},` +
+		`"commit": {}}`
+
+	if b := string(query.Bytes()); b != expected {
+		t.Errorf("Expected %s. Got %s.", expected, b)
+	}
+
+	if query.Len() != 7 {
+		t.Errorf("Expected 7 commands, got %d", query.Len())
+	}
+
+	// Repeated keys are valid JSON, even if Go maps can't hold them
+	var v map[string]interface{}
+	if err := json.Unmarshal(query.Bytes(), &v); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestSolrMixedUpdateQueryEmpty(t *testing.T) {
+	query := NewSolrMixedUpdateQuery()
+	if b := string(query.Bytes()); b != "{}" {
+		t.Errorf("Unexpected query %s", b)
+	}
+
+	query.MaxErrors = 5
+	if query.Handler() != "update" || query.URLParams().Get("maxErrors") != "5" {
+		t.Errorf("Unexpected params %s %v", query.Handler(), query.URLParams())
+	}
+
+	if err := query.AddStruct(struct {
+		ID string `solr:"id"`
+	}{"4"}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if b := string(query.Bytes()); b != `{"add":{"doc":{"id":"4"}}}` {
+		t.Errorf("Unexpected query %s", b)
+	}
+}
@@ -1,8 +1,10 @@
 package gora
 
 import (
+	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wirelessregistry/glog"
@@ -15,40 +17,77 @@ var (
 	ErrTimeout         = errors.New("Host Timeout")
 )
 
+// poolJob pairs a submitted job with the context it was submitted under.
+// attempts counts the hosts that have already failed to run the job.
+type poolJob struct {
+	ctx      context.Context
+	job      SolrJob
+	attempts int
+}
+
+// host is a Solr server shared by a set of workers. Jobs that failed
+// on another host are rerouted to its workers through rerouteCh.
+type host struct {
+	client    SolrClient
+	rerouteCh chan poolJob
+	offline   int32
+}
+
+func (h *host) healthy() bool {
+	return atomic.LoadInt32(&h.offline) == 0
+}
+
+func (h *host) setHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreInt32(&h.offline, 0)
+	} else {
+		atomic.StoreInt32(&h.offline, 1)
+	}
+}
+
 type worker struct {
 	parent     *Pool
 	client     SolrClient
-	jobCh      <-chan SolrJob
+	host       int
+	hosts      []*host
+	jobCh      <-chan poolJob
 	dieCh      <-chan struct{}
 	sigDeathCh chan<- struct{}
 	timeout    int
+	attempts   int
 }
 
-func newWorker(parent *Pool, jCh <-chan SolrJob, dCh <-chan struct{}, sDeathCh chan<- struct{}, client SolrClient, timeout int) *worker {
+func newWorker(parent *Pool, jCh <-chan poolJob, dCh <-chan struct{}, sDeathCh chan<- struct{}, hosts []*host, h int, timeout int) *worker {
 	return &worker{
 		parent:     parent,
 		jobCh:      jCh,
 		dieCh:      dCh,
 		sigDeathCh: sDeathCh,
-		client:     client,
+		client:     hosts[h].client,
+		host:       h,
+		hosts:      hosts,
 		timeout:    timeout,
+		attempts:   parent.jobAttempts(),
 	}
 }
 
 func (w *worker) work() {
-	var resp *SolrResponse
 	var hostReachable <-chan time.Time
 
 	timeout := false
-	jCh := w.jobCh
-	hostReachable = nil
+	reconnects := 0
+	rCh := w.hosts[w.host].rerouteCh
 
 	for {
+		jCh := w.jobCh
 		if timeout {
 			jCh = nil
-			hostReachable = time.After(time.Second * time.Duration(w.timeout))
+			if hostReachable == nil {
+				reconnects++
+				hostReachable = time.After(w.reconnectDelay(reconnects))
+			}
 		} else {
-			jCh = w.jobCh
+			reconnects = 0
 			hostReachable = nil
 		}
 
@@ -57,26 +96,129 @@ func (w *worker) work() {
 			w.sigDeathCh <- struct{}{}
 			return
 
-		case job := <-jCh:
-			resp, timeout = w.client.Execute(job)
+		case pj := <-jCh:
+			timeout = w.process(pj)
+
+		case pj := <-rCh:
+			// Jobs may have been rerouted here before the host went
+			// offline. They are passed on rather than stranded.
 			if timeout {
-				glog.Warning("SolrWorker.timeout received.")
-				resp.Error = ErrTimeout
+				w.handOff(pj)
+			} else {
+				timeout = w.process(pj)
 			}
 
-			job.ResultCh() <- resp
 		case <-hostReachable:
+			hostReachable = nil
 			if glog.V(2) {
 				glog.Info("Trying to reconnect to host...")
 			}
 			timeout = w.hostOffline()
-			glog.Warningf("SolrWorker.hostReachable = %v.", timeout)
+			w.hosts[w.host].setHealthy(!timeout)
+			glog.Warningf("SolrWorker.hostReachable = %v.", !timeout)
+		}
+	}
+}
+
+// process runs a job and delivers its response. If the host failed,
+// the job is handed over to another host when possible, and the host
+// is marked as offline.
+func (w *worker) process(pj poolJob) bool {
+	// Don't bother the host with a job nobody is waiting for
+	if err := pj.ctx.Err(); err != nil {
+		pj.job.ResultCh() <- &SolrResponse{Error: err}
+		return false
+	}
+
+	resp, timeout := w.execute(pj)
+	if timeout {
+		glog.Warning("SolrWorker.timeout received.")
+		w.hosts[w.host].setHealthy(false)
+
+		if w.reroute(pj) {
+			return true
+		}
+
+		// An answer from Solr is worth more to the caller than a timeout
+		var solrErr *SolrError
+		if !errors.As(resp.Error, &solrErr) {
+			resp.Error = ErrTimeout
+		}
+	}
+
+	pj.job.ResultCh() <- resp
+	return timeout
+}
+
+// reroute hands a job that failed on this worker's host to the workers
+// of the next healthy host. It returns false if the job has used up its
+// attempts, or if no other host could take it without blocking.
+func (w *worker) reroute(pj poolJob) bool {
+	pj.attempts++
+	if pj.attempts >= w.attempts {
+		return false
+	}
+
+	for i := 1; i < len(w.hosts); i++ {
+		h := w.hosts[(w.host+i)%len(w.hosts)]
+		if !h.healthy() {
+			continue
+		}
+
+		select {
+		case h.rerouteCh <- pj:
+			if glog.V(2) {
+				glog.Infof("SolrWorker rerouted job, attempt %d.", pj.attempts+1)
+			}
+			return true
+		default:
+		}
+	}
+
+	return false
+}
+
+// handOff reroutes a job this worker's offline host can't run, or fails
+// it if no other host can take it.
+func (w *worker) handOff(pj poolJob) {
+	if !w.reroute(pj) {
+		pj.job.ResultCh() <- &SolrResponse{Error: ErrTimeout}
+	}
+}
+
+// execute runs the job, retrying it on this worker's host for as long
+// as the pool's RetryPolicy allows. A client with a RetryPolicy of its
+// own retries the job itself, and is left to it.
+func (w *worker) execute(pj poolJob) (*SolrResponse, bool) {
+	retry := w.parent.retry
+	if c, ok := w.client.(retryingClient); ok && c.retryPolicy() != nil {
+		retry = nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, timeout := executeContext(pj.ctx, w.client, pj.job)
+		if !shouldRetry(retry, attempt, resp.Error) {
+			return resp, timeout
+		}
+
+		if err := sleepContext(pj.ctx, retry.Backoff(attempt)); err != nil {
+			return &SolrResponse{Error: err}, false
 		}
 	}
 }
 
+// reconnectDelay returns how long to wait before the n-th attempt
+// to reach an offline host.
+func (w *worker) reconnectDelay(n int) time.Duration {
+	if w.parent.retry != nil {
+		return w.parent.retry.Backoff(n)
+	}
+
+	return time.Second * time.Duration(w.timeout)
+}
+
 func (w *worker) hostOffline() bool {
-	return w.client.TestConnection()
+	return !w.client.TestConnection()
 }
 
 // Pool holds all the data about our worker pool
@@ -90,9 +232,14 @@ type Pool struct {
 	clients []SolrClient
 
 	timeout int
-	jobCh   chan SolrJob
-	dieCh   chan struct{}
-	lock    sync.Mutex
+	retry   RetryPolicy
+
+	// attempts is the number of hosts a job may be tried on, see SetJobAttempts
+	attempts int
+
+	jobCh chan poolJob
+	dieCh chan struct{}
+	lock  sync.Mutex
 }
 
 // NewPool will create a Pool structure with an array of Solr servers.
@@ -107,6 +254,35 @@ func NewPool(clients []SolrClient, numWorkersPerClient, bufLen, timeout int) *Po
 	return p
 }
 
+// NewPoolWithRetryPolicy creates a Pool whose workers consult the given
+// RetryPolicy before failing a job on a retryable error, and use its
+// backoff instead of the fixed timeout between reconnection attempts.
+// Clients with a RetryPolicy of their own are not retried by the pool.
+func NewPoolWithRetryPolicy(clients []SolrClient, numWorkersPerClient, bufLen, timeout int, retry RetryPolicy) *Pool {
+	p := NewPool(clients, numWorkersPerClient, bufLen, timeout)
+	p.retry = retry
+	return p
+}
+
+// SetJobAttempts sets the number of hosts a job may be tried on. When a
+// host fails a job, the job is handed to the workers of another healthy
+// host until this budget is spent. It defaults to the number of hosts,
+// and takes effect on the next call to Run.
+func (p *Pool) SetJobAttempts(n int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.attempts = n
+}
+
+func (p *Pool) jobAttempts() int {
+	if p.attempts > 0 {
+		return p.attempts
+	}
+
+	return len(p.clients)
+}
+
 // Run will create a goroutine for each worker, and a master goroutine
 // to control those workers. A channel to close down the pool will be
 // returned to the caller.
@@ -120,30 +296,47 @@ func (p *Pool) Run() (<-chan struct{}, error) {
 
 	glog.Infof("SolrPool.Run() with %v worker(s).", p.nWorkersPerClient)
 
-	p.jobCh = make(chan SolrJob, p.bufferLen)
+	p.jobCh = make(chan poolJob, p.bufferLen)
 	p.dieCh = make(chan struct{}, 1)
 
 	sigPoolDeathCh := make(chan struct{}, 1)
 	collectWorkersDeathCh := make(chan struct{}, p.nWorkersPerClient)
 	dieChs := make([]chan struct{}, 0, p.nWorkersPerClient)
 
-	for _, client := range p.clients {
+	hosts := make([]*host, len(p.clients))
+	for h, client := range p.clients {
+		hosts[h] = &host{
+			client:    client,
+			rerouteCh: make(chan poolJob, p.bufferLen+p.nWorkersPerClient),
+		}
+	}
+
+	for h := range hosts {
 		for i := 0; i < p.nWorkersPerClient; i++ {
 			dieCh := make(chan struct{}, 1)
 			dieChs = append(dieChs, dieCh)
 
-			w := newWorker(p, p.jobCh, dieCh, collectWorkersDeathCh, client, p.timeout)
+			w := newWorker(p, p.jobCh, dieCh, collectWorkersDeathCh, hosts, h, p.timeout)
 			go w.work()
 		}
 	}
 
-	go master(p.dieCh, dieChs, sigPoolDeathCh, collectWorkersDeathCh)
+	go master(p.dieCh, dieChs, sigPoolDeathCh, collectWorkersDeathCh, p.jobCh, hosts)
 
 	return sigPoolDeathCh, nil
 }
 
 // Submit will enter a job into the queue for the worker pool
 func (p *Pool) Submit(s SolrJob) error {
+	return p.SubmitContext(context.Background(), s)
+}
+
+// SubmitContext will enter a job into the queue for the worker pool.
+// If the context is done while waiting for room in the queue, the
+// context's error is returned and the job is not queued. A queued job
+// whose context is done by the time a worker picks it up is not sent
+// to Solr; its SolrResponse carries the context's error instead.
+func (p *Pool) SubmitContext(ctx context.Context, s SolrJob) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -151,11 +344,16 @@ func (p *Pool) Submit(s SolrJob) error {
 		return ErrPoolNotRunning
 	}
 
-	p.jobCh <- s
-	return nil
+	select {
+	case p.jobCh <- poolJob{ctx: ctx, job: s}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// Stop will gracefully stop processing jobs and shutdown the workers
+// Stop will gracefully stop processing jobs and shutdown the workers.
+// The jobs still queued once the workers are gone get ErrPoolNotRunning.
 func (p *Pool) Stop() {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -168,11 +366,17 @@ func (p *Pool) Stop() {
 	p.jobCh = nil
 }
 
-func master(dieCh chan struct{}, dieChs []chan struct{}, sigPoolDeathCh chan<- struct{}, collectWorkersDeathCh <-chan struct{}) {
+func master(dieCh chan struct{}, dieChs []chan struct{}, sigPoolDeathCh chan<- struct{}, collectWorkersDeathCh <-chan struct{}, jobCh chan poolJob, hosts []*host) {
 	workersLeft := len(dieChs)
 
 	for {
 		if workersLeft == 0 {
+			// Nobody is left to run the queued jobs, or to add to them
+			failJobs(jobCh)
+			for _, h := range hosts {
+				failJobs(h.rerouteCh)
+			}
+
 			sigPoolDeathCh <- struct{}{}
 			return
 		}
@@ -188,3 +392,15 @@ func master(dieCh chan struct{}, dieChs []chan struct{}, sigPoolDeathCh chan<- s
 		}
 	}
 }
+
+// failJobs reports the jobs left in a channel as not run.
+func failJobs(ch chan poolJob) {
+	for {
+		select {
+		case pj := <-ch:
+			pj.job.ResultCh() <- &SolrResponse{Error: ErrPoolNotRunning}
+		default:
+			return
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package gora
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -96,3 +101,230 @@ func TestPoolFaultyWorker(t *testing.T) {
 	p.Stop()
 	<-sig
 }
+
+func TestPoolSubmitContext(t *testing.T) {
+	ch := make(chan struct{}, 1)
+	client := MockClientConstructor("0.0.0.0", "", ch)
+	p := NewPool([]SolrClient{client}, 1, 1, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := p.SubmitContext(ctx, nil)
+	if err != ErrPoolNotRunning {
+		t.Fatalf("Expected errPoolNotRunning")
+	}
+
+	sig, _ := p.Run()
+
+	job := NewMockSolrJob([]byte("1"))
+	p.SubmitContext(ctx, job)
+	resp := job.Wait()
+	if resp.Error != nil {
+		t.Errorf("Unexpected error %v", resp.Error)
+	}
+
+	cancel()
+
+	// The job is either refused outright, or skipped by the worker
+	job = NewMockSolrJob([]byte("2"))
+	err = p.SubmitContext(ctx, job)
+	if err == nil {
+		err = job.Wait().Error
+	}
+
+	if err != context.Canceled {
+		t.Errorf("Expected %v. Got %v.", context.Canceled, err)
+	}
+
+	p.Stop()
+	<-sig
+}
+
+// flakyClient times out a fixed number of times before succeeding.
+type flakyClient struct {
+	MockSolrClient
+	failures int
+	delay    time.Duration
+}
+
+func (c *flakyClient) ExecuteContext(ctx context.Context, s SolrJob) (*SolrResponse, bool) {
+	time.Sleep(c.delay)
+
+	if c.failures > 0 {
+		c.failures--
+		return &SolrResponse{Error: ErrTimeout}, true
+	}
+
+	return &SolrResponse{}, false
+}
+
+func (c *flakyClient) TestConnection() bool {
+	return true
+}
+
+func TestPoolRetryPolicy(t *testing.T) {
+	client := &flakyClient{failures: 2}
+	retry := NewBackoffRetryPolicy(3, time.Millisecond, 10*time.Millisecond)
+
+	p := NewPoolWithRetryPolicy([]SolrClient{client}, 1, 0, 1, retry)
+	sig, _ := p.Run()
+
+	job := NewMockSolrJob([]byte("1"))
+	p.Submit(job)
+
+	resp := job.Wait()
+	if resp.Error != nil {
+		t.Errorf("Expected the job to succeed on retry, got %v", resp.Error)
+	}
+
+	p.Stop()
+	<-sig
+}
+
+func TestPoolRetryableStatus(t *testing.T) {
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewHttpSolrClient(server.URL, "core").(*HttpSolrClient)
+	client.RetryPolicy = NewBackoffRetryPolicy(2, time.Millisecond, time.Millisecond)
+	retry := NewBackoffRetryPolicy(3, time.Millisecond, time.Millisecond)
+
+	p := NewPoolWithRetryPolicy([]SolrClient{client}, 1, 0, 1, retry)
+	sig, _ := p.Run()
+
+	job := NewSolrQuery("*:*", 0, 10, nil, nil, nil, "select")
+	p.Submit(job)
+
+	// The Solr error reaches the caller, and the host stays up
+	resp := job.Wait()
+	var solrErr *SolrError
+	if !errors.As(resp.Error, &solrErr) || solrErr.HTTPStatus != 503 {
+		t.Errorf("Expected a 503 SolrError, got %v", resp.Error)
+	}
+
+	// Only the client's policy applies
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("Expected 2 requests, got %d", n)
+	}
+
+	job = NewSolrQuery("*:*", 0, 10, nil, nil, nil, "select")
+	p.Submit(job)
+	job.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != 4 {
+		t.Errorf("Expected the host to take the next job, got %d requests", n)
+	}
+
+	p.Stop()
+	<-sig
+}
+
+func TestPoolReroute(t *testing.T) {
+	ch := make(chan struct{}, 1)
+	dead := &flakyClient{failures: 1 << 30}
+	alive := MockClientConstructor("0.0.0.0", "", ch)
+
+	p := NewPool([]SolrClient{dead, alive}, 1, 0, 1)
+	sig, _ := p.Run()
+
+	for i := 0; i < 10; i++ {
+		job := NewMockSolrJob([]byte(strconv.Itoa(i)))
+		p.Submit(job)
+
+		resp := job.Wait()
+		if resp.Error != nil {
+			t.Errorf("Expected job %d to be rerouted, got %v", i, resp.Error)
+		}
+	}
+
+	p.Stop()
+	<-sig
+
+	// Without a second attempt the failure reaches the caller
+	dead = &flakyClient{failures: 1 << 30}
+	p = NewPool([]SolrClient{dead, alive}, 1, 0, 1)
+	p.SetJobAttempts(1)
+	sig, _ = p.Run()
+
+	deadline := time.After(5 * time.Second)
+	for timedOut := false; !timedOut; {
+		job := NewMockSolrJob([]byte("1"))
+		p.Submit(job)
+
+		select {
+		case resp := <-job.ResultCh():
+			timedOut = resp.Error == ErrTimeout
+		case <-deadline:
+			t.Fatal("Expected the failure to reach the caller")
+		}
+	}
+
+	p.Stop()
+	<-sig
+}
+
+func TestPoolRerouteOffline(t *testing.T) {
+	first := &flakyClient{failures: 1 << 30, delay: 10 * time.Millisecond}
+	second := &flakyClient{failures: 1 << 30, delay: 10 * time.Millisecond}
+
+	// Reconnect quickly, without retrying the jobs on the same host
+	reconnect := NewBackoffRetryPolicy(1, time.Millisecond, 10*time.Millisecond)
+
+	p := NewPoolWithRetryPolicy([]SolrClient{first, second}, 1, 10, 1, reconnect)
+	sig, _ := p.Run()
+
+	// Jobs rerouted to a host that then goes offline are not stranded
+	jobs := make([]*MockSolrJob, 10)
+	for i := range jobs {
+		jobs[i] = NewMockSolrJob([]byte(strconv.Itoa(i)))
+		p.Submit(jobs[i])
+	}
+
+	deadline := time.After(10 * time.Second)
+	for i, job := range jobs {
+		select {
+		case resp := <-job.ResultCh():
+			if resp.Error != ErrTimeout {
+				t.Errorf("Expected %v for job %d, got %v", ErrTimeout, i, resp.Error)
+			}
+		case <-deadline:
+			t.Fatalf("Job %d was never answered", i)
+		}
+	}
+
+	p.Stop()
+	<-sig
+}
+
+func TestPoolStopQueuedJobs(t *testing.T) {
+	client := &flakyClient{delay: 50 * time.Millisecond}
+
+	p := NewPool([]SolrClient{client}, 1, 10, 1)
+	sig, _ := p.Run()
+
+	jobs := make([]*MockSolrJob, 5)
+	for i := range jobs {
+		jobs[i] = NewMockSolrJob([]byte(strconv.Itoa(i)))
+		p.Submit(jobs[i])
+	}
+
+	p.Stop()
+	<-sig
+
+	// Every job is either run or failed, none is left waiting
+	for i, job := range jobs {
+		select {
+		case resp := <-job.ResultCh():
+			if resp.Error != nil && resp.Error != ErrPoolNotRunning {
+				t.Errorf("Unexpected error %v for job %d", resp.Error, i)
+			}
+		default:
+			t.Errorf("Job %d was never answered", i)
+		}
+	}
+}